@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -20,17 +21,28 @@ const (
 )
 
 type Env struct {
-	jsonstore jsonstore.Client
+	jsonstore *jsonstore.HttpClient
+	todos     *jsonstore.TypedClient[Todo]
 	metadata  Metadata
 }
 
-func (env *Env) nextId() int {
-	nextId := env.metadata.NextId
-	err := env.jsonstore.Put("metadata/nextId", nextId+1)
-	if err == nil {
-		env.metadata.NextId = nextId + 1
+func (env *Env) nextId() (int, error) {
+	var nextId int
+	err := env.jsonstore.Update("metadata/nextId", func(current json.RawMessage) (interface{}, error) {
+		nextId = 0
+		if current != nil {
+			err := json.Unmarshal(current, &nextId)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nextId + 1, nil
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nextId
+	env.metadata.NextId = nextId + 1
+	return nextId, nil
 }
 
 func (env *Env) addTodo() error {
@@ -38,9 +50,12 @@ func (env *Env) addTodo() error {
 	if err != nil {
 		return errors.New("No todo title provided")
 	}
-	todoId := env.nextId()
+	todoId, err := env.nextId()
+	if err != nil {
+		return err
+	}
 	todo := NewTodo(todoId, title)
-	err = env.jsonstore.Post(fmt.Sprintf("todos/%d", todoId), todo)
+	err = env.todos.Set(fmt.Sprintf("todos/%d", todoId), todo)
 	if err != nil {
 		return err
 	}
@@ -66,8 +81,7 @@ func (env *Env) completeTodo() error {
 	if err != nil {
 		return err
 	}
-	var todo Todo
-	err = env.jsonstore.Get(fmt.Sprintf("todos/%d", ID), &todo)
+	todo, err := env.todos.Get(fmt.Sprintf("todos/%d", ID))
 	if err != nil {
 		fmt.Printf("Todo with id %d set to done\n", ID)
 	}
@@ -86,28 +100,24 @@ func (env *Env) deleteTodo() error {
 }
 
 func getEnv() *Env {
-	db := jsonstore.NewClient(getStoreToken())
+	db, err := jsonstore.NewClientFromEnv()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	var metadata Metadata
-	err := db.Get("metadata", &metadata)
+	err = db.Get("metadata", &metadata)
 	if err != nil {
 		fmt.Printf("Could not get todos metadata. Error: %s\n", err)
 		os.Exit(1)
 	}
 	return &Env{
 		jsonstore: db,
+		todos:     jsonstore.NewTyped[Todo](db),
 		metadata:  metadata,
 	}
 }
 
-func getStoreToken() string {
-	token := os.Getenv("JSONSTORE_TOKEN")
-	if token == "" {
-		fmt.Println("No jsonstore token found")
-		os.Exit(1)
-	}
-	return token
-}
-
 func main() {
 	env := getEnv()
 	subCommand, err := getCommandAt(1)
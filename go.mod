@@ -0,0 +1,5 @@
+module github.com/CzarSimon/jsonstore-go-client
+
+go 1.20
+
+require golang.org/x/time v0.5.0
@@ -0,0 +1,123 @@
+package jsonstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveTimeoutMultiple is the multiple of the average latency given to a request.
+	adaptiveTimeoutMultiple = 3
+	// adaptiveTimeoutWeight is the weight of the latest latency in the moving average.
+	adaptiveTimeoutWeight = 0.2
+)
+
+// WithAdaptiveTimeout gives every request sent, including each retry, a deadline of three
+// times the average latency of recent requests, clamped to [min, max]. The average is an
+// exponentially weighted moving average in which the latest request weighs 20%, so a slow
+// server raises the timeout within a few requests and a fast one lowers it again.
+// Latency is measured until the response headers arrive. Requests cut off by the adaptive
+// deadline count with the timeout they were given, other failed requests are not counted.
+// Until the first request completes max is used. Requests cut off by the deadline fail with
+// an error matching context.DeadlineExceeded and are retried like network errors.
+func WithAdaptiveTimeout(min, max time.Duration) Option {
+	return func(c *HttpClient) {
+		if max < min {
+			max = min
+		}
+		c.adaptive = &adaptiveTimeout{
+			min: min,
+			max: max,
+		}
+	}
+}
+
+type adaptiveTimeout struct {
+	min time.Duration
+	max time.Duration
+
+	mu      sync.Mutex
+	average time.Duration
+}
+
+// timeout returns the timeout for the next request.
+func (a *adaptiveTimeout) timeout() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.average == 0 {
+		return a.max
+	}
+	timeout := a.average * adaptiveTimeoutMultiple
+	if timeout < a.min {
+		return a.min
+	}
+	if timeout > a.max {
+		return a.max
+	}
+	return timeout
+}
+
+// withDeadline returns r with a context that is cancelled once the adaptive timeout has
+// passed on clock, along with the timeout and the function releasing the context.
+// Returns r and a nil function if there is no adaptive timeout.
+func (a *adaptiveTimeout) withDeadline(r *http.Request, clock Clock) (*http.Request, time.Duration, context.CancelFunc) {
+	if a == nil {
+		return r, 0, nil
+	}
+	timeout := a.timeout()
+	if _, ok := clock.(realClock); ok {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		return r.WithContext(ctx), timeout, cancel
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	expired := clock.After(timeout)
+	go func() {
+		select {
+		case <-expired:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return r.WithContext(ctx), timeout, cancel
+}
+
+// adaptiveTimeoutError is returned for requests cut off by the adaptive timeout. It is a
+// net.Error reporting a timeout, so the request is retried like other network errors, and
+// matches context.DeadlineExceeded with errors.Is.
+type adaptiveTimeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *adaptiveTimeoutError) Error() string {
+	return fmt.Sprintf("Request exceeded adaptive timeout of %s: %s", e.timeout, e.err)
+}
+
+func (e *adaptiveTimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+func (e *adaptiveTimeoutError) Timeout() bool {
+	return true
+}
+
+func (e *adaptiveTimeoutError) Temporary() bool {
+	return true
+}
+
+// record adds the latency of a request to the moving average.
+func (a *adaptiveTimeout) record(latency time.Duration) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.average == 0 {
+		a.average = latency
+		return
+	}
+	a.average += time.Duration(adaptiveTimeoutWeight * float64(latency-a.average))
+}
@@ -0,0 +1,92 @@
+package jsonstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveTimeoutFollowsLatency(t *testing.T) {
+	a := &adaptiveTimeout{min: 50 * time.Millisecond, max: 2 * time.Second}
+	if timeout := a.timeout(); timeout != 2*time.Second {
+		t.Fatalf("expected max before any request, got %s", timeout)
+	}
+
+	a.record(100 * time.Millisecond)
+	if timeout := a.timeout(); timeout != 300*time.Millisecond {
+		t.Errorf("expected 3x the first latency, got %s", timeout)
+	}
+	for i := 0; i < 30; i++ {
+		a.record(5 * time.Millisecond)
+	}
+	if timeout := a.timeout(); timeout != 50*time.Millisecond {
+		t.Errorf("expected timeout clamped to min for a fast server, got %s", timeout)
+	}
+
+	previous := a.timeout()
+	for i := 0; i < 5; i++ {
+		a.record(400 * time.Millisecond)
+		timeout := a.timeout()
+		if timeout <= previous {
+			t.Errorf("expected timeout to grow with slower responses, got %s after %s", timeout, previous)
+		}
+		previous = timeout
+	}
+	for i := 0; i < 30; i++ {
+		a.record(time.Second)
+	}
+	if timeout := a.timeout(); timeout != 2*time.Second {
+		t.Errorf("expected timeout clamped to max for a slow server, got %s", timeout)
+	}
+}
+
+func TestAdaptiveTimeoutWithVaryingLatencies(t *testing.T) {
+	clock := newManualClock()
+	var latency, cutoff atomic.Int64
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		d := time.Duration(latency.Load())
+		clock.Advance(d)
+		if d >= time.Duration(cutoff.Load()) {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+		writeResult(w, `1`)
+	}, WithAdaptiveTimeout(100*time.Millisecond, 5*time.Second), WithClock(clock))
+
+	get := func(d time.Duration) error {
+		latency.Store(int64(d))
+		cutoff.Store(int64(c.adaptive.timeout()))
+		return c.Get("counter", new(int))
+	}
+	for i := 0; i < 20; i++ {
+		err := get(200 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i+1, err)
+		}
+	}
+	if timeout := c.adaptive.timeout(); timeout < 550*time.Millisecond || timeout > 650*time.Millisecond {
+		t.Fatalf("expected timeout near 600ms after 200ms responses, got %s", timeout)
+	}
+
+	err := get(time.Second)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected slow request to time out, got %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		get(time.Second)
+	}
+	if timeout := c.adaptive.timeout(); timeout < 2*time.Second {
+		t.Fatalf("expected timeout to grow under sustained load, got %s", timeout)
+	}
+	err = get(time.Second)
+	if err != nil {
+		t.Fatalf("expected request to succeed once the timeout has adapted, got %v", err)
+	}
+}
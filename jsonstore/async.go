@@ -0,0 +1,88 @@
+package jsonstore
+
+import (
+	"context"
+	"sync"
+)
+
+// AsyncWriter posts values to jsonstore in the background using a fixed number of workers.
+type AsyncWriter struct {
+	client *HttpClient
+	ctx    context.Context
+	ops    chan asyncOp
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+type asyncOp struct {
+	key  string
+	v    interface{}
+	done chan<- error
+}
+
+// NewAsyncWriter starts an AsyncWriter posting through c with as many workers as the
+// concurrency of c, see WithConcurrency. Posts are sent with ctx, so once it is done
+// posts in flight are cancelled and queued posts fail with the error of ctx.
+// Close should be called to stop the workers.
+func (c *HttpClient) NewAsyncWriter(ctx context.Context) *AsyncWriter {
+	workers := c.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	client := c.With()
+	client.ctx = ctx
+	w := &AsyncWriter{
+		client: client,
+		ctx:    ctx,
+		ops:    make(chan asyncOp),
+	}
+	w.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go w.work()
+	}
+	return w
+}
+
+// PostAsync queues a Post of v to key and returns a channel that receives its result.
+// When all workers are busy PostAsync blocks until one is free or ctx is done, so
+// producers are slowed down to the pace of the workers rather than buffered without bound.
+func (w *AsyncWriter) PostAsync(key string, v interface{}) <-chan error {
+	done := make(chan error, 1)
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		done <- ErrWriterClosed
+		return done
+	}
+	select {
+	case w.ops <- asyncOp{key: key, v: v, done: done}:
+	case <-w.ctx.Done():
+		done <- w.ctx.Err()
+	}
+	return done
+}
+
+// Close waits for queued posts to finish and stops the workers.
+// Posts queued after Close fail with ErrWriterClosed.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.ops)
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+	return nil
+}
+
+func (w *AsyncWriter) work() {
+	defer w.wg.Done()
+	for op := range w.ops {
+		err := w.ctx.Err()
+		if err == nil {
+			err = w.client.Post(op.key, op.v)
+		}
+		op.done <- err
+	}
+}
@@ -0,0 +1,102 @@
+package jsonstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// blockingHandler answers requests from h once release is closed, signalling each
+// request on started, or fails them when the client goes away.
+func blockingHandler(h http.Handler, started chan<- struct{}, release <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			h.ServeHTTP(w, r)
+		case <-r.Context().Done():
+		}
+	}
+}
+
+func TestAsyncWriterBackpressure(t *testing.T) {
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	c := newTestClient(t, blockingHandler(newStoreHandler(), started, release), WithConcurrency(1))
+	w := c.NewAsyncWriter(context.Background())
+	defer w.Close()
+
+	first := w.PostAsync("todos/1", 1)
+	<-started
+	queued := make(chan (<-chan error), 1)
+	go func() { queued <- w.PostAsync("todos/2", 2) }()
+	select {
+	case <-queued:
+		t.Fatal("Expected PostAsync to block while the worker is busy")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	second := <-queued
+	for i, done := range []<-chan error{first, second} {
+		if err := <-done; err != nil {
+			t.Errorf("Post %d: unexpected error: %v", i+1, err)
+		}
+	}
+}
+
+func TestAsyncWriterContextCancelled(t *testing.T) {
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	defer close(release)
+	c := newTestClient(t, blockingHandler(newStoreHandler(), started, release), WithConcurrency(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	w := c.NewAsyncWriter(ctx)
+	defer w.Close()
+
+	inFlight := w.PostAsync("todos/1", 1)
+	<-started
+	queued := make(chan (<-chan error), 1)
+	go func() { queued <- w.PostAsync("todos/2", 2) }()
+
+	cancel()
+	if err := <-inFlight; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the post in flight to be cancelled, got %v", err)
+	}
+	if err := <-<-queued; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the queued post to fail with context.Canceled, got %v", err)
+	}
+	if err := <-w.PostAsync("todos/3", 3); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected posts after cancel to fail with context.Canceled, got %v", err)
+	}
+}
+
+func TestAsyncWriterCloseDrainsQueue(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, h.ServeHTTP, WithConcurrency(2))
+	w := c.NewAsyncWriter(context.Background())
+
+	results := make([]<-chan error, 10)
+	for i := range results {
+		results[i] = w.PostAsync("todos/"+strconv.Itoa(i), i)
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.mu.Lock()
+	stored := len(h.values)
+	h.mu.Unlock()
+	if stored != len(results) {
+		t.Errorf("Expected Close to wait for all %d posts, %d were stored", len(results), stored)
+	}
+	for i, done := range results {
+		if err := <-done; err != nil {
+			t.Errorf("Post %d: unexpected error: %v", i, err)
+		}
+	}
+	if err := <-w.PostAsync("todos/late", 1); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Expected ErrWriterClosed after Close, got %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ItemResult outcome of a single item in a batch response.
+type ItemResult struct {
+	OK     bool            `json:"ok"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// BatchResponse structure of batch responses with per item results,
+// e.g. {"ok": false, "results": {"a": {"ok": true}, "b": {"ok": false, "error": "..."}}}.
+type BatchResponse struct {
+	Results map[string]ItemResult `json:"results"`
+	OK      bool                  `json:"ok"`
+}
+
+// GetMany gets the values of several keys concurrently, returning the raw response for each key.
+// Failing keys are left out of the result and their errors are returned in a BatchError.
+func (c *HttpClient) GetMany(keys []string) (map[string][]byte, error) {
+	results := make(map[string][]byte, len(keys))
+	var mu sync.Mutex
+	err := c.forEachKey(keys, func(key string) error {
+		data, err := c.GetBytes(key)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		results[key] = data
+		mu.Unlock()
+		return nil
+	})
+	return results, err
+}
+
+// DeleteMany deletes several keys concurrently. Every key is attempted
+// and the errors of the failed deletes are returned in a BatchError.
+func (c *HttpClient) DeleteMany(keys []string) error {
+	return c.forEachKey(keys, c.Delete)
+}
+
+// PostMany posts the value of each key in items concurrently, using at most the number
+// of requests set with WithConcurrency at once. Every key is attempted, so some values
+// may be stored when others fail, and the errors of the failed posts are returned in a
+// BatchError. Each key is written with its own request as writing a common parent object
+// instead would replace any other values stored beneath it.
+func (c *HttpClient) PostMany(items map[string]interface{}) error {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return c.forEachKey(keys, func(key string) error {
+		return c.Post(key, items[key])
+	})
+}
+
+// SetObject writes all fields of m as a single object at key with one PUT request,
+// which is much cheaper than writing each field with PostMany. The stored value is
+// replaced, not merged, so fields stored at key but missing from m are removed.
+func (c *HttpClient) SetObject(key string, m map[string]interface{}) error {
+	return c.Put(key, m)
+}
+
+// BatchPutOne updates the value of a key and returns the per item results of the response,
+// which may include failed items without an error being returned. Plain responses are
+// reported as a single item for key, unless they are not ok in which case they fail like Put.
+//
+// Experimental: jsonstore has no batch endpoints yet, this exists so that
+// batch response handling is in place once it does.
+func (c *HttpClient) BatchPutOne(key string, v interface{}) (map[string]ItemResult, error) {
+	body, err := c.marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newBytesRequest(http.MethodPut, key, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return newBatchResults(key, req.URL.String(), data)
+}
+
+func newBatchResults(key, url string, data []byte) (map[string]ItemResult, error) {
+	var batch BatchResponse
+	err := json.Unmarshal(data, &batch)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Results != nil {
+		return batch.Results, nil
+	}
+	resp, err := newResponse(http.MethodPut, key, data)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.OK {
+		return nil, resp.failure(fmt.Sprintf("Failed to store resource at '%s' (%s)", key, url))
+	}
+	return map[string]ItemResult{
+		key: {OK: resp.OK, Result: resp.Result},
+	}, nil
+}
+
+// BatchStats describes the last batch operation, such as GetMany, of a client.
+type BatchStats struct {
+	// Keys is the number of keys in the batch.
+	Keys int
+	// Throttled is the number of keys that failed with a 429 or 5xx status.
+	Throttled int
+	// Concurrency is the number of requests the batch was allowed to send at once
+	// when it finished and MinConcurrency the lowest it was lowered to.
+	Concurrency    int
+	MinConcurrency int
+}
+
+// LastBatchStats returns the stats of the last batch operation of the client, including
+// batches of clients derived with With. Returns the zero BatchStats if no batch has run yet.
+func (c *HttpClient) LastBatchStats() BatchStats {
+	stats := c.lastBatch.Load()
+	if stats == nil {
+		return BatchStats{}
+	}
+	return *stats
+}
+
+// forEachKey calls fn for each key concurrently and returns the errors of the failed calls
+// in a BatchError. At most c.concurrency calls run at once, fewer while calls are throttled.
+func (c *HttpClient) forEachKey(keys []string, fn func(key string) error) error {
+	workers := c.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	limit := newAIMDLimit(workers)
+	errs := make(map[string]error)
+	var throttled int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		epoch := limit.acquire()
+		go func(key string, epoch int) {
+			defer wg.Done()
+			err := fn(key)
+			isThrottled := isThrottled(err)
+			if err != nil {
+				mu.Lock()
+				errs[key] = err
+				if isThrottled {
+					throttled++
+				}
+				mu.Unlock()
+			}
+			limit.release(epoch, isThrottled)
+		}(key, epoch)
+	}
+	wg.Wait()
+	concurrency, minConcurrency := limit.stats()
+	c.lastBatch.Store(&BatchStats{
+		Keys:           len(keys),
+		Throttled:      throttled,
+		Concurrency:    concurrency,
+		MinConcurrency: minConcurrency,
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+	return &BatchError{Errors: errs}
+}
+
+// isThrottled reports whether err is a 429 or 5xx response, which batch operations
+// take as a sign of sending too many requests at once.
+func isThrottled(err error) bool {
+	code, ok := StatusCode(err)
+	return ok && (code == http.StatusTooManyRequests || code >= 500)
+}
+
+// aimdLimit limits the number of calls running at once with additive increase and
+// multiplicative decrease: the limit is halved when a call is throttled and raised
+// by one once a limit's worth of calls have succeeded, up to max.
+type aimdLimit struct {
+	max int
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  float64
+	min    float64
+	active int
+	epoch  int
+}
+
+func newAIMDLimit(max int) *aimdLimit {
+	l := &aimdLimit{
+		max:   max,
+		limit: float64(max),
+		min:   float64(max),
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire waits until fewer calls than the limit are running
+// and returns the epoch the call is started in.
+func (l *aimdLimit) acquire() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= int(l.limit) {
+		l.cond.Wait()
+	}
+	l.active++
+	return l.epoch
+}
+
+// release ends a call started in epoch. Only the first throttled call of an epoch lowers
+// the limit, as the other calls running at the time were started under the old limit.
+func (l *aimdLimit) release(epoch int, throttled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if throttled {
+		if epoch == l.epoch {
+			l.limit = math.Max(1, l.limit/2)
+			l.min = math.Min(l.min, l.limit)
+			l.epoch++
+		}
+	} else {
+		l.limit = math.Min(float64(l.max), l.limit+1/l.limit)
+	}
+	l.cond.Broadcast()
+}
+
+func (l *aimdLimit) stats() (limit, min int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit), int(l.min)
+}
+
+// BatchError error returned by batch operations when some keys failed,
+// holding the error of each failed key. errors.Is matches the error of any key.
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	failed := e.Failed()
+	msgs := make([]string, len(failed))
+	for i, key := range failed {
+		msgs[i] = fmt.Sprintf("%s: %s", key, e.Errors[key])
+	}
+	return fmt.Sprintf("Batch failed for %d keys: %s", len(failed), strings.Join(msgs, "; "))
+}
+
+// Failed returns the failed keys in sorted order.
+func (e *BatchError) Failed() []string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Unwrap returns the errors of the failed keys.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, key := range e.Failed() {
+		errs = append(errs, e.Errors[key])
+	}
+	return errs
+}
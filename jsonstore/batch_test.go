@@ -0,0 +1,211 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetObjectSendsSingleRequest(t *testing.T) {
+	rr := &requestRecorder{}
+	c := newTestClient(t, rr.ServeHTTP)
+
+	err := c.SetObject("todos", map[string]interface{}{
+		"1": map[string]interface{}{"title": "First", "done": false},
+		"2": map[string]interface{}{"title": "Second", "done": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rr.requests) != 1 {
+		t.Fatalf("Expected a single request, got %d", len(rr.requests))
+	}
+	r := rr.requests[0]
+	if r.Method != http.MethodPut || r.URL.Path != "/store/todos" {
+		t.Errorf("Expected PUT /store/todos, got %s %s", r.Method, r.URL.Path)
+	}
+	expected := `{"1":{"done":false,"title":"First"},"2":{"done":true,"title":"Second"}}`
+	if rr.bodies[0] != expected {
+		t.Errorf("Expected body %s, got %s", expected, rr.bodies[0])
+	}
+}
+
+// failingKeysHandler answers requests for keys starting with "missing" with 404 Not Found
+// and for keys starting with "broken" with 500, storing everything else in h.
+func failingKeysHandler(h *storeHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/store/")
+		switch {
+		case strings.HasPrefix(key, "missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(key, "broken"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			h.ServeHTTP(w, r)
+		}
+	}
+}
+
+func TestBatchErrorMixedBatches(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, failingKeysHandler(h), WithConcurrency(3))
+	expectedFailed := []string{"broken/1", "missing/1", "missing/2"}
+
+	err := c.PostMany(map[string]interface{}{
+		"todos/1": 1, "todos/2": 2, "missing/1": 3, "broken/1": 4, "missing/2": 5,
+	})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected BatchError from PostMany, got %v", err)
+	}
+	if !reflect.DeepEqual(batchErr.Failed(), expectedFailed) {
+		t.Errorf("Expected %v to fail, got %v", expectedFailed, batchErr.Failed())
+	}
+	if len(h.values) != 2 {
+		t.Errorf("Expected the 2 other values to be stored, got %v", h.values)
+	}
+
+	results, err := c.GetMany([]string{"todos/1", "missing/1", "todos/2", "broken/1", "missing/2"})
+	if !errors.As(err, &batchErr) || !reflect.DeepEqual(batchErr.Failed(), expectedFailed) {
+		t.Errorf("Expected GetMany to fail for %v, got %v", expectedFailed, err)
+	}
+	if len(results) != 2 || string(results["todos/1"]) != `{"ok":true,"result":1}` {
+		t.Errorf("Expected results for the 2 other keys, got %v", results)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected BatchError to match ErrNotFound, got %v", err)
+	}
+	if code, _ := StatusCode(batchErr.Errors["broken/1"]); code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for broken/1, got %v", batchErr.Errors["broken/1"])
+	}
+	if !strings.HasPrefix(err.Error(), "Batch failed for 3 keys: broken/1: ") {
+		t.Errorf("Unexpected error message: %s", err)
+	}
+
+	err = c.DeleteMany([]string{"todos/1", "todos/2"})
+	if err != nil {
+		t.Errorf("Expected successful batch to return nil, got %v", err)
+	}
+	err = c.DeleteMany([]string{"missing/1"})
+	if !errors.As(err, &batchErr) || !reflect.DeepEqual(batchErr.Failed(), []string{"missing/1"}) {
+		t.Errorf("Expected DeleteMany to fail for missing/1, got %v", err)
+	}
+}
+
+// throttlingHandler answers with 429 Too Many Requests while more than limit requests are in
+// flight and serves the others from h after delay, recording the most requests served at once.
+type throttlingHandler struct {
+	h     http.Handler
+	limit int32
+	delay time.Duration
+
+	inFlight  atomic.Int32
+	throttled atomic.Int32
+}
+
+func (th *throttlingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := th.inFlight.Add(1)
+	defer th.inFlight.Add(-1)
+	if n > th.limit {
+		th.throttled.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	time.Sleep(th.delay)
+	th.h.ServeHTTP(w, r)
+}
+
+func TestBatchBacksOffWhenThrottled(t *testing.T) {
+	th := &throttlingHandler{h: newStoreHandler(), limit: 2, delay: 5 * time.Millisecond}
+	c := newTestClient(t, th.ServeHTTP, WithConcurrency(8))
+
+	items := make(map[string]interface{}, 60)
+	for i := 0; i < 60; i++ {
+		items[fmt.Sprintf("todos/%d", i)] = i
+	}
+	err := c.PostMany(items)
+	var batchErr *BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		t.Fatalf("Expected BatchError from PostMany, got %v", err)
+	}
+	stats := c.LastBatchStats()
+	if stats.Keys != len(items) {
+		t.Errorf("Expected stats for %d keys, got %d", len(items), stats.Keys)
+	}
+	if n := int(th.throttled.Load()); stats.Throttled != n || n == 0 {
+		t.Errorf("Expected %d throttled keys, got %d", n, stats.Throttled)
+	}
+	if stats.MinConcurrency > 2 {
+		t.Errorf("Expected concurrency to be lowered to the 2 requests the server accepts, got %d", stats.MinConcurrency)
+	}
+	if stats.Concurrency >= 8 {
+		t.Errorf("Expected concurrency to stay below the configured 8, got %d", stats.Concurrency)
+	}
+	if stats.Throttled > len(items)/2 {
+		t.Errorf("Expected most keys to be written once backed off, %d of %d were throttled", stats.Throttled, len(items))
+	}
+}
+
+func TestBatchKeepsConcurrencyWhenNotThrottled(t *testing.T) {
+	c := newTestClient(t, newStoreHandler().ServeHTTP, WithConcurrency(4))
+
+	err := c.DeleteMany([]string{"a", "b", "c", "d", "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := BatchStats{Keys: 5, Concurrency: 4, MinConcurrency: 4}
+	if stats := c.LastBatchStats(); stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+}
+
+// rawHandler answers every request with body.
+func rawHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}
+}
+
+func TestBatchPutOneItemResults(t *testing.T) {
+	c := newTestClient(t, rawHandler(`{"ok":false,"results":{"a":{"ok":true,"result":1},"b":{"ok":false,"error":"Value too large"}}}`))
+
+	results, err := c.BatchPutOne("a", 1)
+	if err != nil {
+		t.Fatalf("Expected failed items to be reported in the results, got %v", err)
+	}
+	expected := map[string]ItemResult{
+		"a": {OK: true, Result: json.RawMessage(`1`)},
+		"b": {OK: false, Error: "Value too large"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected results %+v, got %+v", expected, results)
+	}
+}
+
+func TestBatchPutOnePlainResponse(t *testing.T) {
+	c := newTestClient(t, rawHandler(`{"ok":true,"result":2}`))
+	results, err := c.BatchPutOne("a", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]ItemResult{"a": {OK: true, Result: json.RawMessage(`2`)}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected results %+v, got %+v", expected, results)
+	}
+
+	c = newTestClient(t, rawHandler(`{"ok":false,"result":"Store is read only"}`))
+	results, err = c.BatchPutOne("a", 2)
+	if err == nil || !strings.Contains(err.Error(), "Store is read only") {
+		t.Errorf("Expected a not ok plain response to fail with its message, got %v", err)
+	}
+	if results != nil {
+		t.Errorf("Expected no results, got %+v", results)
+	}
+}
@@ -0,0 +1,70 @@
+package jsonstore
+
+import (
+	"sync"
+	"time"
+)
+
+// WithCircuitBreaker makes the client fail fast with ErrCircuitOpen after threshold
+// consecutive requests have failed with a network error, a 429 or a 5xx status.
+// Once cooldown has passed a single trial request is sent, closing the circuit if it
+// succeeds and keeping it open for another cooldown if it fails.
+// A threshold less than 1 disables the circuit breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *HttpClient) {
+		if threshold < 1 {
+			c.breaker = nil
+			return
+		}
+		c.breaker = &circuitBreaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+		}
+	}
+}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// allow reports whether a request may be sent at now.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.trial || now.Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record updates the breaker with the outcome of a request sent at now,
+// failed reporting whether the request counts as a failure.
+func (b *circuitBreaker) record(failed bool, now time.Time) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = now
+	}
+}
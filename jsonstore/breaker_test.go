@@ -0,0 +1,146 @@
+package jsonstore
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	h := &faultyHandler{}
+	h.failWith(http.StatusServiceUnavailable)
+	c := newTestClient(t, h.ServeHTTP, WithCircuitBreaker(3, time.Minute), WithClock(newFakeClock()))
+
+	var v int
+	for i := 0; i < 3; i++ {
+		err := c.Get("counter", &v)
+		if code, _ := StatusCode(err); code != http.StatusServiceUnavailable {
+			t.Fatalf("request %d: expected HTTPError with status 503, got %v", i+1, err)
+		}
+	}
+	err := c.Get("counter", &v)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if n := h.requests.Load(); n != 3 {
+		t.Errorf("expected 3 requests to reach the server, got %d", n)
+	}
+}
+
+func TestCircuitBreakerRecoversAfterCooldown(t *testing.T) {
+	h := &faultyHandler{}
+	h.failWith(http.StatusServiceUnavailable)
+	clock := newFakeClock()
+	c := newTestClient(t, h.ServeHTTP, WithCircuitBreaker(2, time.Minute), WithClock(clock))
+
+	var v int
+	c.Get("counter", &v)
+	c.Get("counter", &v)
+	clock.Advance(59 * time.Second)
+	err := c.Get("counter", &v)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen before the cooldown has passed, got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	err = c.Get("counter", &v)
+	if code, _ := StatusCode(err); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected failed trial request, got %v", err)
+	}
+	err = c.Get("counter", &v)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen after a failed trial request, got %v", err)
+	}
+
+	h.failWith(0)
+	clock.Advance(time.Minute)
+	for i := 0; i < 3; i++ {
+		err = c.Get("counter", &v)
+		if err != nil {
+			t.Fatalf("request %d after recovery: unexpected error: %v", i+1, err)
+		}
+	}
+	if n := h.requests.Load(); n != 6 {
+		t.Errorf("expected 6 requests to reach the server, got %d", n)
+	}
+}
+
+func TestCircuitBreakerAllowsSingleTrial(t *testing.T) {
+	b := &circuitBreaker{threshold: 1, cooldown: time.Minute}
+	now := time.Now()
+	b.record(true, now)
+	if b.allow(now) {
+		t.Fatal("expected open circuit to reject requests")
+	}
+	now = now.Add(time.Minute)
+	if !b.allow(now) {
+		t.Fatal("expected trial request after cooldown")
+	}
+	if b.allow(now) {
+		t.Fatal("expected requests to be rejected while the trial request is in flight")
+	}
+	b.record(false, now)
+	if !b.allow(now) || !b.allow(now) {
+		t.Fatal("expected closed circuit after successful trial request")
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	h := &faultyHandler{}
+	h.failWith(http.StatusNotFound)
+	c := newTestClient(t, h.ServeHTTP,
+		WithCircuitBreaker(1, time.Minute), WithClock(newFakeClock()), WithRoundTripper(MaxBodySize(4)))
+
+	var v int
+	for i := 0; i < 3; i++ {
+		err := c.Get("counter", &v)
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+		err = c.PutReader("counter", strings.NewReader(`"too large"`))
+		if !errors.Is(err, ErrPayloadTooLarge) {
+			t.Fatalf("expected ErrPayloadTooLarge, got %v", err)
+		}
+	}
+	if n := h.requests.Load(); n != 3 {
+		t.Errorf("expected 3 requests to reach the server, got %d", n)
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	h := &faultyHandler{}
+	c := newTestClient(t, h.ServeHTTP, WithCircuitBreaker(2, time.Minute), WithClock(newFakeClock()))
+
+	var v int
+	for i := 0; i < 5; i++ {
+		h.failWith(http.StatusBadGateway)
+		c.Get("counter", &v)
+		h.failWith(0)
+		err := c.Get("counter", &v)
+		if err != nil {
+			t.Fatalf("round %d: unexpected error: %v", i+1, err)
+		}
+	}
+}
+
+func TestCircuitBreakerDisabledByInvalidThreshold(t *testing.T) {
+	for _, threshold := range []int{0, -1} {
+		h := &faultyHandler{}
+		h.failWith(http.StatusServiceUnavailable)
+		c := newTestClient(t, h.ServeHTTP,
+			WithCircuitBreaker(3, time.Minute), WithCircuitBreaker(threshold, time.Minute), WithClock(newFakeClock()))
+
+		var v int
+		for i := 0; i < 5; i++ {
+			err := c.Get("counter", &v)
+			if code, _ := StatusCode(err); code != http.StatusServiceUnavailable {
+				t.Fatalf("threshold %d, request %d: expected HTTPError with status 503, got %v", threshold, i+1, err)
+			}
+		}
+		if n := h.requests.Load(); n != 5 {
+			t.Errorf("threshold %d: expected all 5 requests to reach the server, got %d", threshold, n)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package jsonstore
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCacheEntries is the number of responses kept by the cache enabled with WithCache.
+const maxCacheEntries = 1024
+
+// WithCache makes the client cache the responses of Get and GetBytes in memory for ttl.
+// Writes through the client invalidate the cached responses of the written key and of
+// the keys above and below it. At most 1024 responses are cached, evicting those closest
+// to expiring first. Writes made by other clients are not seen until the ttl expires.
+func WithCache(ttl time.Duration) Option {
+	return func(c *HttpClient) {
+		c.cache = newResponseCache(ttl, maxCacheEntries)
+	}
+}
+
+// GetCachedOnError gets a value like Get, but always asks jsonstore for the current value.
+// If that fails with a transient error, see IsTransient, the last response cached for key
+// with WithCache is decoded into v instead, even if its ttl has passed, and an error matching
+// both ErrServedStale and the error of the request is returned. Without a cached response,
+// or without WithCache, the error of the request is returned as is.
+func (c *HttpClient) GetCachedOnError(key string, v interface{}) error {
+	req, err := c.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return err
+	}
+	url := req.URL.String()
+	data, err := c.readBytes(req)
+	if err == nil {
+		if c.cache != nil {
+			c.cache.set(url, data, c.clock.Now())
+		}
+		return c.decodeResult(key, data, v)
+	}
+	if c.cache == nil || !IsTransient(err) {
+		return err
+	}
+	stale, ok := c.cache.stale(url)
+	if !ok || c.decodeResult(key, stale, v) != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrServedStale, err)
+}
+
+type cacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+type responseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]cacheEntry
+}
+
+func newResponseCache(ttl time.Duration, size int) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// get returns the cached response for url, reporting false if there is none at now.
+// Expired responses are kept until evicted, to be served by GetCachedOnError.
+func (rc *responseCache) get(url string, now time.Time) ([]byte, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[url]
+	if !ok || now.After(entry.expires) {
+		return nil, false
+	}
+	return copyBytes(entry.data), true
+}
+
+// stale returns the cached response for url, whether or not it has expired.
+func (rc *responseCache) stale(url string) ([]byte, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[url]
+	if !ok {
+		return nil, false
+	}
+	return copyBytes(entry.data), true
+}
+
+// set caches data as the response for url from now until the ttl has passed.
+func (rc *responseCache) set(url string, data []byte, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if _, ok := rc.entries[url]; !ok && len(rc.entries) >= rc.size {
+		rc.evict(now)
+	}
+	rc.entries[url] = cacheEntry{
+		data:    copyBytes(data),
+		expires: now.Add(rc.ttl),
+	}
+}
+
+// invalidate removes the cached responses for url and for all urls above or below it.
+func (rc *responseCache) invalidate(url string) {
+	url = strings.TrimSuffix(url, "/")
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for cached := range rc.entries {
+		if cached == url || strings.HasPrefix(cached, url+"/") || strings.HasPrefix(url, cached+"/") {
+			delete(rc.entries, cached)
+		}
+	}
+}
+
+// evict removes expired entries, or the entry closest to expiring if none have expired.
+func (rc *responseCache) evict(now time.Time) {
+	var oldest string
+	var oldestExpires time.Time
+	for url, entry := range rc.entries {
+		if now.After(entry.expires) {
+			delete(rc.entries, url)
+			continue
+		}
+		if oldest == "" || entry.expires.Before(oldestExpires) {
+			oldest = url
+			oldestExpires = entry.expires
+		}
+	}
+	if len(rc.entries) >= rc.size {
+		delete(rc.entries, oldest)
+	}
+}
+
+func copyBytes(data []byte) []byte {
+	return append([]byte(nil), data...)
+}
@@ -0,0 +1,230 @@
+package jsonstore
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetCachedOnErrorServesStaleValue(t *testing.T) {
+	h := &faultyHandler{}
+	h.result.Store(`"fresh"`)
+	clock := newFakeClock()
+	c := newTestClient(t, h.ServeHTTP, WithCache(time.Minute), WithClock(clock))
+
+	var v string
+	err := c.GetCachedOnError("todos/1", &v)
+	if err != nil || v != "fresh" {
+		t.Fatalf("expected fresh value, got %q, %v", v, err)
+	}
+
+	h.failWith(http.StatusServiceUnavailable)
+	clock.Advance(time.Hour)
+	v = ""
+	err = c.GetCachedOnError("todos/1", &v)
+	if !errors.Is(err, ErrServedStale) {
+		t.Fatalf("expected ErrServedStale, got %v", err)
+	}
+	if code, _ := StatusCode(err); code != http.StatusServiceUnavailable {
+		t.Errorf("expected error to wrap the failed request, got %v", err)
+	}
+	if v != "fresh" {
+		t.Errorf("expected stale value to be decoded, got %q", v)
+	}
+	if n := h.requests.Load(); n != 2 {
+		t.Errorf("expected every call to ask the server, got %d requests", n)
+	}
+}
+
+func TestGetCachedOnErrorRefreshesCache(t *testing.T) {
+	h := &faultyHandler{}
+	h.result.Store(`"first"`)
+	c := newTestClient(t, h.ServeHTTP, WithCache(time.Minute), WithClock(newFakeClock()))
+
+	var v string
+	c.Get("todos/1", &v)
+	h.result.Store(`"second"`)
+	err := c.GetCachedOnError("todos/1", &v)
+	if err != nil || v != "second" {
+		t.Fatalf("expected fresh value despite the cache, got %q, %v", v, err)
+	}
+	h.failWith(http.StatusBadGateway)
+	err = c.GetCachedOnError("todos/1", &v)
+	if !errors.Is(err, ErrServedStale) || v != "second" {
+		t.Fatalf("expected the refreshed value to be served stale, got %q, %v", v, err)
+	}
+}
+
+func TestGetCachedOnErrorReturnsError(t *testing.T) {
+	h := &faultyHandler{}
+	h.result.Store(`"fresh"`)
+	c := newTestClient(t, h.ServeHTTP, WithCache(time.Minute), WithClock(newFakeClock()))
+	uncached := newTestClient(t, h.ServeHTTP)
+
+	var v string
+	c.Get("todos/1", &v)
+	uncached.Get("todos/1", &v)
+
+	h.failWith(http.StatusNotFound)
+	err := c.GetCachedOnError("todos/1", &v)
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrServedStale) {
+		t.Errorf("expected non transient error to be returned as is, got %v", err)
+	}
+
+	h.failWith(http.StatusServiceUnavailable)
+	err = c.GetCachedOnError("todos/2", &v)
+	if code, _ := StatusCode(err); code != http.StatusServiceUnavailable || errors.Is(err, ErrServedStale) {
+		t.Errorf("expected error without a cached value, got %v", err)
+	}
+	err = uncached.GetCachedOnError("todos/1", &v)
+	if code, _ := StatusCode(err); code != http.StatusServiceUnavailable || errors.Is(err, ErrServedStale) {
+		t.Errorf("expected error without a cache, got %v", err)
+	}
+}
+
+// storeHandler serves values from a map by request path, storing the bodies of writes.
+type storeHandler struct {
+	mu     sync.Mutex
+	values map[string]string
+	gets   int
+}
+
+func newStoreHandler() *storeHandler {
+	return &storeHandler{values: make(map[string]string)}
+}
+
+func (h *storeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		h.gets++
+		value, ok := h.values[r.URL.Path]
+		if !ok {
+			value = "null"
+		}
+		writeResult(w, value)
+	case http.MethodDelete:
+		delete(h.values, r.URL.Path)
+		writeResult(w, "null")
+	default:
+		body, _ := ioutil.ReadAll(r.Body)
+		h.values[r.URL.Path] = string(body)
+		writeResult(w, "null")
+	}
+}
+
+func (h *storeHandler) Gets() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.gets
+}
+
+func TestCacheServesRepeatedGets(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, h.ServeHTTP, WithCache(time.Minute))
+	c.Post("todos/1", "write tests")
+
+	for i := 0; i < 3; i++ {
+		var v string
+		err := c.Get("todos/1", &v)
+		if err != nil || v != "write tests" {
+			t.Fatalf("get %d: expected cached value, got %q, %v", i+1, v, err)
+		}
+		data, err := c.GetBytes("todos/1")
+		if err != nil || string(data) != `{"ok":true,"result":"write tests"}` {
+			t.Fatalf("get bytes %d: expected cached response, got %s, %v", i+1, data, err)
+		}
+	}
+	if gets := h.Gets(); gets != 1 {
+		t.Errorf("expected a single GET to reach the server, got %d", gets)
+	}
+}
+
+func TestCacheIsInvalidatedByWrites(t *testing.T) {
+	writes := []struct {
+		name  string
+		key   string
+		write func(c *HttpClient) error
+	}{
+		{"Post", "todos/1", func(c *HttpClient) error { return c.Post("todos/1", "second") }},
+		{"Put", "todos/1", func(c *HttpClient) error { return c.Put("todos/1", "second") }},
+		{"Delete", "todos/1", func(c *HttpClient) error { return c.Delete("todos/1") }},
+		{"Put parent", "todos/1", func(c *HttpClient) error { return c.Put("todos", map[string]string{"1": "second"}) }},
+		{"Delete child", "todos", func(c *HttpClient) error { return c.Delete("todos/1") }},
+	}
+	for _, tt := range writes {
+		h := newStoreHandler()
+		c := newTestClient(t, h.ServeHTTP, WithCache(time.Minute))
+		c.Post("todos/1", "first")
+
+		c.GetBytes(tt.key)
+		err := tt.write(c)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		c.GetBytes(tt.key)
+		if gets := h.Gets(); gets != 2 {
+			t.Errorf("%s: expected the write to invalidate the cached response, got %d GETs", tt.name, gets)
+		}
+	}
+}
+
+func TestCacheKeepsUnrelatedKeys(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, h.ServeHTTP, WithCache(time.Minute))
+
+	c.GetBytes("todos/1")
+	c.GetBytes("todos/10")
+	c.Post("todos/2", "other")
+	c.Post("todos/1x", "other")
+	c.GetBytes("todos/1")
+	c.GetBytes("todos/10")
+	if gets := h.Gets(); gets != 2 {
+		t.Errorf("expected writes of other keys to keep the cache, got %d GETs", gets)
+	}
+}
+
+func TestCacheIsBounded(t *testing.T) {
+	rc := newResponseCache(time.Minute, 3)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		rc.set(fmt.Sprintf("https://example.com/store/%d", i), []byte("data"), now.Add(time.Duration(i)*time.Second))
+	}
+	if n := len(rc.entries); n != 3 {
+		t.Fatalf("expected 3 cached responses, got %d", n)
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := rc.get(fmt.Sprintf("https://example.com/store/%d", i), now); ok {
+			t.Errorf("expected response %d closest to expiring to be evicted", i)
+		}
+	}
+	if _, ok := rc.get("https://example.com/store/4", now); !ok {
+		t.Error("expected the latest response to be cached")
+	}
+}
+
+func TestCacheIsSafeForConcurrentUse(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, h.ServeHTTP, WithCache(time.Minute))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("todos/%d", i%3)
+			for j := 0; j < 20; j++ {
+				if j%5 == 0 {
+					c.Post(key, j)
+				}
+				c.GetBytes(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
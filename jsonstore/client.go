@@ -2,6 +2,8 @@ package jsonstore
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,15 +11,20 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"strings"
+	"sync/atomic"
 	"time"
-)
 
-var (
-	JsonstoreUrl, _ = url.Parse("https://www.jsonstore.io")
-	ErrNoValue      = errors.New("No value for key")
+	"golang.org/x/time/rate"
 )
 
+// Version of the jsonstore client.
+const Version = "0.1.0"
+
+var JsonstoreUrl, _ = url.Parse("https://www.jsonstore.io")
+
 // Client interface for jsonstore client implementations.
 type Client interface {
 	Get(key string, v interface{}) error // Done
@@ -30,28 +37,165 @@ type Client interface {
 	PutBytes(key string, data []byte) error
 
 	Delete(key string) error
+
+	Exists(key string) (bool, error)
 }
 
+var _ io.Closer = (*HttpClient)(nil)
+
 // HttpClient main http client for interacting with jsonstore.
 type HttpClient struct {
-	httpClient *http.Client
-	baseURL    *url.URL
+	httpClient      *http.Client
+	transport       *http.Transport
+	baseURL         *url.URL
+	storeKey        string
+	pathPrefix      string
+	successStatuses map[int]bool
+	timeout         time.Duration
+	retry           retryConfig
+	concurrency     int
+	middleware      []Middleware
+	userAgent       string
+	headers         http.Header
+	compress        bool
+	logf            func(ctx context.Context, info RequestInfo)
+	observer        Observer
+	requestID       func() string
+	idempotency     bool
+	limiter         *rate.Limiter
+	cache           *responseCache
+	request         requestConfig
+	closed          *closeState
+	maxResponseSize int64
+	hasMaxSize      bool
+	codec           Codec
+	timeFormat      string
+	responseDecoder func(data []byte) (json.RawMessage, bool, error)
+	proxy           func(*http.Request) (*url.URL, error)
+	tlsConfig       *tls.Config
+	maxIdleConns    int
+	maxIdlePerHost  int
+	idleConnTimeout time.Duration
+	dialTimeout     time.Duration
+	tlsTimeout      time.Duration
+	validator       func(v interface{}) error
+	lastMeta        *atomic.Pointer[ResponseMeta]
+	lastIO          *atomic.Pointer[ioStats]
+	lastBatch       *atomic.Pointer[BatchStats]
+	dryRun          func(method, url string, body []byte)
+	breaker         *circuitBreaker
+	adaptive        *adaptiveTimeout
+	clock           Clock
+	largeChunkSize  int
+	ctx             context.Context
 }
 
 // Response structure of responses returned from jsonstore.
+// Result holds the raw JSON of the result, which is null for missing keys.
 type Response struct {
-	Result interface{} `json:"result"`
-	OK     bool        `json:"ok"`
+	Result json.RawMessage `json:"result"`
+	OK     bool            `json:"ok"`
+}
+
+// HasResult reports whether the response holds a non null result.
+func (resp *Response) HasResult() bool {
+	return len(resp.Result) > 0 && string(resp.Result) != "null"
+}
+
+// NewClient creates a new HttpClient. The store key is not checked,
+// see NewClientChecked.
+func NewClient(storeKey string, opts ...Option) *HttpClient {
+	return newClient(*JsonstoreUrl, storeKey, opts)
 }
 
-// NewClient creates a new HttpClient.
-func NewClient(storeKey string) *HttpClient {
-	url := JsonstoreUrl
-	url.Path = storeKey
-	return &HttpClient{
-		httpClient: createNetHttpClient(),
-		baseURL:    url,
+// NewClientChecked creates a new HttpClient like NewClient, but returns an error
+// for an empty store key, which would address the root of jsonstore, or a store key
+// containing characters that are not URL safe.
+func NewClientChecked(storeKey string, opts ...Option) (*HttpClient, error) {
+	if JsonstoreUrl == nil {
+		return nil, errors.New("Invalid jsonstore url")
+	}
+	err := validateStoreKey(storeKey)
+	if err != nil {
+		return nil, err
 	}
+	return NewClient(storeKey, opts...), nil
+}
+
+// NewClientWithURL creates a new HttpClient for a jsonstore compatible backend at baseURL.
+func NewClientWithURL(baseURL, storeKey string, opts ...Option) (*HttpClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Invalid base url '%s'", baseURL)
+	}
+	return newClient(*u, storeKey, opts), nil
+}
+
+const (
+	// TokenEnv is the environment variable NewClientFromEnv reads the store key from.
+	TokenEnv = "JSONSTORE_TOKEN"
+	// URLEnv is the environment variable NewClientFromEnv reads the base url from.
+	URLEnv = "JSONSTORE_URL"
+)
+
+// NewClientFromEnv creates a new HttpClient for the store key in JSONSTORE_TOKEN,
+// returning an error if it is not set. If JSONSTORE_URL is set it is used as the
+// base url like in NewClientWithURL, otherwise JsonstoreUrl is used.
+func NewClientFromEnv(opts ...Option) (*HttpClient, error) {
+	token := os.Getenv(TokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("No jsonstore token found in %s", TokenEnv)
+	}
+	err := validateStoreKey(token)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := os.Getenv(URLEnv)
+	if baseURL == "" {
+		return NewClientChecked(token, opts...)
+	}
+	return NewClientWithURL(baseURL, token, opts...)
+}
+
+func newClient(baseURL url.URL, storeKey string, opts []Option) *HttpClient {
+	c := &HttpClient{
+		storeKey:        strings.Trim(storeKey, "/"),
+		successStatuses: statusSet(defaultSuccessStatuses),
+		timeout:         defaultTimeout,
+		concurrency:     defaultConcurrency,
+		userAgent:       defaultUserAgent,
+		clock:           realClock{},
+		closed:          &closeState{},
+		lastMeta:        &atomic.Pointer[ResponseMeta]{},
+		lastIO:          &atomic.Pointer[ioStats]{},
+		lastBatch:       &atomic.Pointer[BatchStats]{},
+		maxResponseSize: defaultMaxResponseSize,
+		maxIdleConns:    defaultMaxIdleConns,
+		maxIdlePerHost:  defaultMaxIdleConnsPerHost,
+		idleConnTimeout: defaultIdleConnTimeout,
+		codec:           jsonCodec{},
+		largeChunkSize:  defaultLargeChunkSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.timeFormat != "" {
+		c.codec = timeFormatCodec{codec: c.codec, layout: c.timeFormat}
+	}
+	baseURL.Path = path.Join("/", baseURL.Path, c.pathPrefix, storeKey)
+	c.baseURL = &baseURL
+	if c.httpClient == nil {
+		c.httpClient = c.createNetHttpClient()
+	}
+	if len(c.middleware) > 0 {
+		hc := *c.httpClient
+		hc.Transport = chainMiddleware(hc.Transport, c.middleware)
+		c.httpClient = &hc
+	}
+	return c
 }
 
 // Get gets value from jsonstore.
@@ -60,39 +204,121 @@ func (c *HttpClient) Get(key string, v interface{}) error {
 	if err != nil {
 		return err
 	}
-	resp, err := newResponse(rawResponse)
+	return c.decodeResult(key, rawResponse, v)
+}
+
+func (c *HttpClient) decodeResult(key string, rawResponse []byte, v interface{}) error {
+	resp, err := c.parseResponse(http.MethodGet, key, rawResponse)
 	if err != nil {
 		return err
 	}
-	if resp.Result == nil {
+	if !resp.HasResult() {
 		return ErrNoValue
 	}
 	if !resp.OK {
-		return fmt.Errorf("Could not get resource '%s'", key)
+		return resp.failure(fmt.Sprintf("Could not get resource '%s' (%s)", key, c.createURL(key)))
 	}
-	return resp.unmarshallResult(v)
+	return resp.unmarshallResult(c.codec, v)
 }
 
 // GetBytes gets value from jsonstore as a bytes.
 func (c *HttpClient) GetBytes(key string) ([]byte, error) {
-	req, err := newRequest(http.MethodGet, c.createURL(key), nil)
+	req, err := c.newRequest(http.MethodGet, key, nil)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.httpClient.Do(req)
+	if c.cache == nil {
+		return c.readBytes(req)
+	}
+	url := req.URL.String()
+	if data, ok := c.cache.get(url, c.clock.Now()); ok {
+		return data, nil
+	}
+	data, err := c.readBytes(req)
 	if err != nil {
 		return nil, err
 	}
+	c.cache.set(url, data, c.clock.Now())
+	return data, nil
+}
+
+func (c *HttpClient) readBytes(req *http.Request) ([]byte, error) {
+	data, _, err := c.readResponse(req)
+	return data, err
+}
+
+func (c *HttpClient) readResponse(req *http.Request) ([]byte, http.Header, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Non OK status: %d", resp.StatusCode)
+	data, err := ioutil.ReadAll(resp.Body)
+	return data, resp.Header, err
+}
+
+// GetBytesChecked gets a value from jsonstore as bytes like GetBytes, but returns
+// ErrNoValue if no value is stored at key, as Get does. GetBytes returns the
+// response of jsonstore as is, also for missing keys.
+func (c *HttpClient) GetBytesChecked(key string) ([]byte, error) {
+	rawResponse, err := c.GetBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.parseResponse(http.MethodGet, key, rawResponse)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.HasResult() {
+		return nil, ErrNoValue
+	}
+	return rawResponse, nil
+}
+
+// GetRaw gets the raw JSON of a value from jsonstore, unwrapped from the response
+// of jsonstore but not decoded. Returns ErrNoValue if no value is stored at key.
+func (c *HttpClient) GetRaw(key string) (json.RawMessage, error) {
+	rawResponse, err := c.GetBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.parseResponse(http.MethodGet, key, rawResponse)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.HasResult() {
+		return nil, ErrNoValue
+	}
+	if !resp.OK {
+		return nil, resp.failure(fmt.Sprintf("Could not get resource '%s' (%s)", key, c.createURL(key)))
+	}
+	return resp.Result, nil
+}
+
+// GetTo copies the response from jsonstore for a key into w without buffering it in memory,
+// returning the number of bytes written. As it is meant for large values the response is not
+// limited by the default response size limit, only by a limit set with WithMaxResponseSize.
+func (c *HttpClient) GetTo(key string, w io.Writer) (int64, error) {
+	client := c
+	if !c.hasMaxSize {
+		client = c.With()
+		client.maxResponseSize = 0
+	}
+	req, err := client.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.do(req)
+	if err != nil {
+		return 0, err
 	}
-	return ioutil.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	return io.Copy(w, resp.Body)
 }
 
 // Post posts a value in jsonstore.
 func (c *HttpClient) Post(key string, v interface{}) error {
-	body, err := json.Marshal(v)
+	body, err := c.marshal(v)
 	if err != nil {
 		return err
 	}
@@ -101,17 +327,22 @@ func (c *HttpClient) Post(key string, v interface{}) error {
 
 // PostBytes posts raw bytes to jsonstore.
 func (c *HttpClient) PostBytes(key string, data []byte) error {
-	req, err := newRequest(http.MethodPost, c.createURL(key), bytes.NewBuffer(data))
+	_, err := c.sendBytes(http.MethodPost, key, data)
+	return err
+}
+
+// PostResponse posts a value in jsonstore and returns the response of jsonstore.
+func (c *HttpClient) PostResponse(key string, v interface{}) (*Response, error) {
+	body, err := c.marshal(v)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = c.performRequest(key, req)
-	return err
+	return c.sendBytes(http.MethodPost, key, body)
 }
 
 // Put updates the value of a given key in jsonstore.
 func (c *HttpClient) Put(key string, v interface{}) error {
-	body, err := json.Marshal(v)
+	body, err := c.marshal(v)
 	if err != nil {
 		return err
 	}
@@ -120,17 +351,161 @@ func (c *HttpClient) Put(key string, v interface{}) error {
 
 // PutBytes updates the value of a given key in jsonstore.
 func (c *HttpClient) PutBytes(key string, data []byte) error {
-	req, err := newRequest(http.MethodPut, c.createURL(key), bytes.NewBuffer(data))
+	_, err := c.sendBytes(http.MethodPut, key, data)
+	return err
+}
+
+// PutResponse updates the value of a given key in jsonstore and returns the response of jsonstore.
+func (c *HttpClient) PutResponse(key string, v interface{}) (*Response, error) {
+	body, err := c.marshal(v)
 	if err != nil {
+		return nil, err
+	}
+	return c.sendBytes(http.MethodPut, key, body)
+}
+
+// marshal validates v with the validator of the client, if any, and marshals it with the codec.
+func (c *HttpClient) marshal(v interface{}) ([]byte, error) {
+	if c.validator != nil {
+		err := c.validator(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.codec.Marshal(v)
+}
+
+// WriteResult describes the outcome of a write. Created reports whether the server
+// responded with 201 Created rather than, for example, 200 for an overwritten value.
+type WriteResult struct {
+	Created bool
+	Status  int
+}
+
+// PostResult posts a value in jsonstore and reports whether it was created.
+func (c *HttpClient) PostResult(key string, v interface{}) (WriteResult, error) {
+	return c.writeResult(http.MethodPost, key, v)
+}
+
+// PutResult updates the value of a given key in jsonstore and reports whether it was created.
+func (c *HttpClient) PutResult(key string, v interface{}) (WriteResult, error) {
+	return c.writeResult(http.MethodPut, key, v)
+}
+
+func (c *HttpClient) writeResult(method, key string, v interface{}) (WriteResult, error) {
+	body, err := c.marshal(v)
+	if err != nil {
+		return WriteResult{}, err
+	}
+	_, status, err := c.sendBytesStatus(method, key, body)
+	if err != nil {
+		return WriteResult{}, err
+	}
+	return WriteResult{Created: status == http.StatusCreated, Status: status}, nil
+}
+
+func (c *HttpClient) sendBytes(method, key string, data []byte) (*Response, error) {
+	resp, _, err := c.sendBytesStatus(method, key, data)
+	return resp, err
+}
+
+// sendBytesStatus sends data to key and returns the decoded response and its status code.
+func (c *HttpClient) sendBytesStatus(method, key string, data []byte) (*Response, int, error) {
+	req, err := c.newBytesRequest(method, key, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return c.performRequestStatus(key, req)
+}
+
+// PostReader posts the contents of r to jsonstore without buffering it in memory.
+// Failed requests are only retried if r can be replayed, that is if it is an io.Seeker,
+// such as an *os.File, or a *bytes.Reader, *bytes.Buffer or *strings.Reader.
+func (c *HttpClient) PostReader(key string, r io.Reader) error {
+	return c.sendReader(http.MethodPost, key, r)
+}
+
+// PutReader updates the value of a given key with the contents of r without buffering it in memory.
+// Failed requests are only retried if r can be replayed, see PostReader.
+func (c *HttpClient) PutReader(key string, r io.Reader) error {
+	return c.sendReader(http.MethodPut, key, r)
+}
+
+func (c *HttpClient) sendReader(method, key string, r io.Reader) error {
+	req, err := c.newRequest(method, key, r)
+	if err != nil {
+		if rc, ok := r.(io.Closer); ok {
+			rc.Close()
+		}
 		return err
 	}
+	if l, ok := r.(interface{ Len() int }); ok && req.ContentLength == 0 {
+		req.ContentLength = int64(l.Len())
+	}
+	if s, ok := r.(io.ReadSeeker); ok && req.GetBody == nil {
+		err = rewindable(req, s)
+		if err != nil {
+			req.Body.Close()
+			return err
+		}
+		if rc, ok := r.(io.Closer); ok {
+			defer rc.Close()
+		}
+	}
 	_, err = c.performRequest(key, req)
 	return err
 }
 
+// rewindable makes the body of req replayable by seeking s back to its current offset.
+// The transport closes the body after each attempt, so the caller must close s.
+func rewindable(req *http.Request, s io.ReadSeeker) error {
+	start, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	req.Body = ioutil.NopCloser(s)
+	req.GetBody = func() (io.ReadCloser, error) {
+		_, err := s.Seek(start, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(s), nil
+	}
+	return nil
+}
+
 // Delete deletes the value of a key in jsonstore.
 func (c *HttpClient) Delete(key string) error {
-	req, err := newRequest(http.MethodDelete, c.createURL(key), nil)
+	_, err := c.DeleteResponse(key)
+	return err
+}
+
+// DeleteIfExists deletes the value of a key in jsonstore, treating a key that
+// is already absent, which jsonstore answers with 404, as successfully deleted.
+func (c *HttpClient) DeleteIfExists(key string) error {
+	err := c.Delete(key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// DeleteResponse deletes the value of a key in jsonstore and returns the response of jsonstore.
+func (c *HttpClient) DeleteResponse(key string) (*Response, error) {
+	req, err := c.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.performRequest(key, req)
+}
+
+// Clear removes everything stored at key by deleting it, rather than
+// setting it to an empty value. An empty key clears the whole store.
+func (c *HttpClient) Clear(key string) error {
+	if strings.Trim(key, "/") != "" {
+		return c.Delete(key)
+	}
+	req, err := c.newURLRequest(c.context(), http.MethodDelete, c.createURL(""), nil)
 	if err != nil {
 		return err
 	}
@@ -138,62 +513,397 @@ func (c *HttpClient) Delete(key string) error {
 	return err
 }
 
-func (c *HttpClient) performRequest(key string, r *http.Request) (*Response, error) {
-	resp, err := c.httpClient.Do(r)
+// Increment adds delta to the integer stored at key and returns the new value.
+// A missing value counts as zero.
+//
+// Increment is built on Update: if the server sends ETags the write is conditional and
+// retried when the value changes in between, so concurrent increments are not lost.
+// Without ETags the read and the write are separate requests and they can be.
+func (c *HttpClient) Increment(key string, delta int) (int, error) {
+	var next int
+	err := c.Update(key, func(current json.RawMessage) (interface{}, error) {
+		value := 0
+		if current != nil && kindOf(current) != KindNull {
+			err := json.Unmarshal(current, &value)
+			if err != nil {
+				return nil, fmt.Errorf("Could not increment '%s': %w", key, err)
+			}
+		}
+		next = value + delta
+		return next, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// Exists checks if a value is stored at a key in jsonstore.
+func (c *HttpClient) Exists(key string) (bool, error) {
+	rawResponse, err := c.GetBytes(key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.parseResponse(http.MethodGet, key, rawResponse)
+	if err != nil {
+		return false, err
+	}
+	return resp.HasResult(), nil
+}
+
+// Ping checks that the store is reachable by sending a HEAD request for the store root.
+// The store is healthy if it responds with one of the client's success statuses
+// (200, 201 or 204 by default), otherwise an HTTPError is returned.
+func (c *HttpClient) Ping(ctx context.Context) error {
+	req, err := c.newURLRequest(ctx, http.MethodHead, c.createURL(""), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Do sends req with the retries, rate limit, middleware and logging of the client, for
+// operations not covered by the other methods. A relative req.URL is resolved as a key
+// in the store, e.g. "todos/1?orderBy=date", and headers not set on req are set as on
+// the client's own requests. Responses without a success status are returned as an
+// HTTPError, otherwise the caller must close the body of the response.
+func (c *HttpClient) Do(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if !req.URL.IsAbs() {
+		key := req.URL.Path
+		if key != "" {
+			err := c.validateKey(key)
+			if err != nil {
+				return nil, err
+			}
+		}
+		u, err := url.Parse(c.createURL(key))
+		if err != nil {
+			return nil, err
+		}
+		u.RawQuery = req.URL.RawQuery
+		req.URL = u
+		req.Host = ""
+		req = req.WithContext(context.WithValue(req.Context(), requestKeyContextKey{}, key))
+	}
+	defaults, err := c.newURLRequest(req.Context(), req.Method, req.URL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := req.Header[RequestIDHeader]; !ok {
+		req = req.WithContext(defaults.Context())
+	}
+	for name, values := range defaults.Header {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = values
+		}
+	}
+	return c.do(req)
+}
+
+// Upsert stores v at key whether or not a value already exists there.
+// The value is updated with PUT, and created with POST if the server responds
+// that the key was not found.
+func (c *HttpClient) Upsert(key string, v interface{}) error {
+	body, err := c.marshal(v)
+	if err != nil {
+		return err
+	}
+	err = c.PutBytes(key, body)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return c.PostBytes(key, body)
+}
+
+// set writes v to key, updating existing values with PUT and creating new ones with POST.
+func (c *HttpClient) set(key string, v interface{}, exists bool) error {
+	if exists {
+		return c.Put(key, v)
+	}
+	return c.Post(key, v)
+}
+
+// Close releases the idle connections of the client. The client, and all clients derived
+// from it with With, cannot be used after Close and return ErrClientClosed.
+// Closing a derived client only closes it and the clients derived from it, the client it
+// was derived from keeps working and its connections are left open. The connections of an
+// http.Client set with WithHTTPClient belong to the caller and are left open as well.
+func (c *HttpClient) Close() error {
+	c.closed.closed.Store(true)
+	if c.closed.parent == nil && c.transport != nil {
+		c.transport.CloseIdleConnections()
+	}
+	return nil
+}
+
+// closeState records whether a client, or any client it was derived from, is closed.
+type closeState struct {
+	closed atomic.Bool
+	parent *closeState
+}
+
+func (s *closeState) isClosed() bool {
+	for ; s != nil; s = s.parent {
+		if s.closed.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *HttpClient) performRequest(key string, r *http.Request) (*Response, error) {
+	storeResp, _, err := c.performRequestStatus(key, r)
+	return storeResp, err
+}
+
+// performRequestStatus sends r and returns the decoded response and its status code.
+func (c *HttpClient) performRequestStatus(key string, r *http.Request) (*Response, int, error) {
+	resp, err := c.do(r)
+	if err != nil {
+		return nil, 0, err
+	}
 	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("Non OK status: %d", resp.StatusCode)
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
 	}
-	var storeResp Response
-	err = json.NewDecoder(resp.Body).Decode(&storeResp)
+	if len(bytes.TrimSpace(data)) == 0 {
+		// Backends may answer with 204 No Content or an empty 2xx body.
+		return &Response{OK: true}, resp.StatusCode, nil
+	}
+	storeResp, err := c.parseResponse(r.Method, key, data)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if !storeResp.OK {
-		return nil, fmt.Errorf("Failed to store resource at '%s'", key)
+		return nil, 0, storeResp.failure(fmt.Sprintf("Failed to store resource at '%s' (%s)", key, r.URL))
 	}
-	return &storeResp, nil
+	return storeResp, resp.StatusCode, nil
 }
 
-func (c *HttpClient) createURL(resourcePath string) string {
-	url := *c.baseURL
-	url.Path = path.Join(url.Path, resourcePath)
-	return url.String()
+// do sends r, retrying it according to the retry settings of the client.
+// Responses without a success status are returned as an HTTPError.
+func (c *HttpClient) do(r *http.Request) (*http.Response, error) {
+	if c.dryRun != nil && isWrite(r.Method) {
+		return c.skipWrite(r)
+	}
+	if c.cache != nil && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		defer c.cache.invalidate(r.URL.String())
+	}
+	if c.request.timeout <= 0 {
+		return c.doWithRetry(r)
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), c.request.timeout)
+	resp, err := c.doWithRetry(r.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (c *HttpClient) doWithRetry(r *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(r)
+		if err == nil {
+			return resp, nil
+		}
+		retry, delay := c.shouldRetry(r, attempt, err)
+		if !retry || r.Context().Err() != nil || !rewindBody(r) {
+			return nil, err
+		}
+		err = sleep(r.Context(), c.clock, delay)
+		if err != nil {
+			return nil, err
+		}
+	}
 }
 
-func (resp *Response) unmarshallResult(v interface{}) error {
-	bytes, err := json.Marshal(resp.Result)
+// send sends r once, returning an HTTPError for responses without a success status.
+func (c *HttpClient) send(r *http.Request) (*http.Response, error) {
+	if c.closed.isClosed() {
+		return nil, ErrClientClosed
+	}
+	err := c.waitForRateLimit(r.Context())
 	if err != nil {
-		return err
+		return nil, err
+	}
+	start := c.clock.Now()
+	if !c.breaker.allow(start) {
+		return nil, ErrCircuitOpen
+	}
+	ctx := r.Context()
+	r, timeout, cancel := c.adaptive.withDeadline(r, c.clock)
+	c.observeStart(r)
+	sent := countRequest(r)
+	resp, err := c.httpClient.Do(r)
+	status := 0
+	if err != nil {
+		c.lastIO.Store(&ioStats{sent: sent.count()})
+	} else {
+		status = resp.StatusCode
+		c.countResponse(sent, resp)
+		c.recordMeta(resp, c.clock.Now())
+		if c.isSuccess(status) {
+			resp, err = decompressResponse(resp)
+			c.limitResponse(resp)
+		} else {
+			err = newHTTPError(resp, c.clock.Now())
+			resp.Body.Close()
+		}
+	}
+	timedOut := status == 0 && err != nil && ctx.Err() == nil && r.Context().Err() != nil
+	if timedOut {
+		err = &adaptiveTimeoutError{timeout: timeout, err: err}
+	}
+	c.breaker.record(err != nil && ctx.Err() == nil && isRetryable(err), c.clock.Now())
+	duration := c.clock.Now().Sub(start)
+	if status != 0 || timedOut {
+		c.adaptive.record(duration)
+	}
+	c.logRequest(r, status, duration, err)
+	c.observeComplete(r, status, duration, err)
+	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
+		return nil, err
+	}
+	if cancel != nil {
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	}
+	return resp, nil
+}
+
+func (c *HttpClient) limitResponse(resp *http.Response) {
+	if resp == nil || c.maxResponseSize <= 0 {
+		return
+	}
+	resp.Body = &maxBytesBody{
+		body:      resp.Body,
+		remaining: c.maxResponseSize,
+		err:       ErrResponseTooLarge,
+	}
+}
+
+func (c *HttpClient) isSuccess(status int) bool {
+	return c.successStatuses[status]
+}
+
+// failure returns an error with msg, followed by the message of the server if the
+// result of the response is a string, as sent by jsonstore for failed requests.
+func (resp *Response) failure(msg string) error {
+	var serverMsg string
+	if json.Unmarshal(resp.Result, &serverMsg) == nil && serverMsg != "" {
+		return fmt.Errorf("%s: %s", msg, serverMsg)
 	}
-	return json.Unmarshal(bytes, v)
+	return errors.New(msg)
 }
 
-func newResponse(data []byte) (*Response, error) {
+func (resp *Response) unmarshallResult(codec Codec, v interface{}) error {
+	return codec.Unmarshal(resp.Result, v)
+}
+
+// parseResponse decodes a response with the response decoder of the client, or like
+// newResponse if there is none, adding the url of key to decode errors.
+func (c *HttpClient) parseResponse(method, key string, data []byte) (*Response, error) {
+	var resp *Response
+	var err error
+	if c.responseDecoder != nil {
+		resp, err = decodeEnvelope(c.responseDecoder, method, key, data)
+	} else {
+		resp, err = newResponse(method, key, data)
+	}
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		decodeErr.URL = c.createURL(key)
+	}
+	return resp, err
+}
+
+func newResponse(method, key string, data []byte) (*Response, error) {
 	var resp Response
 	err := json.Unmarshal(data, &resp)
 	if err != nil {
-		return nil, err
+		return nil, newDecodeError(method, key, data, err)
 	}
 	return &resp, nil
 }
 
-func createNetHttpClient() *http.Client {
-	const TIMEOUT_SECONDS = 5
+// createNetHttpClient creates the http.Client used unless one is set with WithHTTPClient,
+// keeping its transport so that Close can release its connections behind any middleware.
+func (c *HttpClient) createNetHttpClient() *http.Client {
+	c.transport = c.createTransport()
 	return &http.Client{
-		Timeout: TIMEOUT_SECONDS * time.Second,
+		Timeout:   c.timeout,
+		Transport: c.transport,
+	}
+}
+
+func (c *HttpClient) newRequest(method, key string, body io.Reader) (*http.Request, error) {
+	return c.newRequestWithContext(c.context(), method, key, body)
+}
+
+// context returns the context bound to the client with WithContext, or context.Background.
+func (c *HttpClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
+	return c.ctx
 }
 
-func newRequest(method, url string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, body)
+func (c *HttpClient) newRequestWithContext(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	err := c.validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, requestKeyContextKey{}, key)
+	return c.newURLRequest(ctx, method, c.createURL(key), body)
+}
+
+func (c *HttpClient) newURLRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	var id string
+	if c.requestID != nil {
+		id = c.requestID()
+		ctx = context.WithValue(ctx, requestIDContextKey{}, id)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+	if c.compress {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	c.setIdempotencyKey(req)
+	for name, values := range c.headers {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	if c.request.hasContentType {
+		req.Header.Del("Content-Type")
+		if c.request.contentType != "" {
+			req.Header.Set("Content-Type", c.request.contentType)
+		}
+	}
+	for name, values := range c.request.headers {
+		req.Header[name] = append([]string(nil), values...)
+	}
 	return req, nil
 }
@@ -0,0 +1,298 @@
+package jsonstore
+
+import (
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestClient starts a server handling requests with h and returns a client
+// for the store key "store" pointed at it. The server is closed when the test ends.
+func newTestClient(t *testing.T, h http.HandlerFunc, opts ...Option) *HttpClient {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c, err := NewClientWithURL(srv.URL, "store", opts...)
+	if err != nil {
+		t.Fatalf("NewClientWithURL: %v", err)
+	}
+	return c
+}
+
+// writeResult writes a successful jsonstore response with result.
+func writeResult(w http.ResponseWriter, result string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"ok":true,"result":` + result + `}`))
+}
+
+// requestRecorder records the requests it receives and answers them with result,
+// or null if result is empty.
+type requestRecorder struct {
+	result string
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (rr *requestRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	rr.mu.Lock()
+	rr.requests = append(rr.requests, r)
+	rr.bodies = append(rr.bodies, string(body))
+	rr.mu.Unlock()
+	result := rr.result
+	if result == "" {
+		result = "null"
+	}
+	writeResult(w, result)
+}
+
+// paths returns the escaped paths of the recorded requests.
+func (rr *requestRecorder) paths() []string {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	paths := make([]string, len(rr.requests))
+	for i, r := range rr.requests {
+		paths[i] = r.URL.EscapedPath()
+	}
+	return paths
+}
+
+// last returns the last recorded request.
+func (rr *requestRecorder) last() *http.Request {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if len(rr.requests) == 0 {
+		return nil
+	}
+	return rr.requests[len(rr.requests)-1]
+}
+
+func TestNewClientKeepsStoreKeysApart(t *testing.T) {
+	rr := &requestRecorder{}
+	srv := httptest.NewServer(rr)
+	defer srv.Close()
+	defer func(u *url.URL) { JsonstoreUrl = u }(JsonstoreUrl)
+	JsonstoreUrl, _ = url.Parse(srv.URL)
+
+	first := NewClient("first")
+	second := NewClient("second")
+	var v interface{}
+	for _, c := range []*HttpClient{first, second, first} {
+		err := c.Get("todos", &v)
+		if err != nil && !errors.Is(err, ErrNoValue) {
+			t.Fatal(err)
+		}
+	}
+	expected := []string{"/first/todos", "/second/todos", "/first/todos"}
+	paths := rr.paths()
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected %d requests, got %v", len(expected), paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("Request %d: expected path %s, got %s", i, expected[i], paths[i])
+		}
+	}
+	if JsonstoreUrl.Path != "" {
+		t.Errorf("Expected JsonstoreUrl to be left untouched, got path %s", JsonstoreUrl.Path)
+	}
+}
+
+func TestEmptySuccessResponses(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	err := c.Delete("todos/1")
+	if err != nil {
+		t.Errorf("Expected 204 on DELETE to succeed, got %v", err)
+	}
+	err = c.Put("todos/1", true)
+	if err != nil {
+		t.Errorf("Expected empty 200 on PUT to succeed, got %v", err)
+	}
+}
+
+// upsertHandler answers PUT on missing keys with 404 Not Found, like backends
+// that only create values with POST, and records the methods it receives.
+func upsertHandler(values map[string]string, methods *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*methods = append(*methods, r.Method)
+		body, _ := ioutil.ReadAll(r.Body)
+		_, exists := values[r.URL.Path]
+		if r.Method == http.MethodPut && !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		values[r.URL.Path] = string(body)
+		writeResult(w, "null")
+	}
+}
+
+func TestUpsertCreates(t *testing.T) {
+	values := make(map[string]string)
+	var methods []string
+	c := newTestClient(t, upsertHandler(values, &methods))
+
+	err := c.Upsert("todos/1", map[string]bool{"done": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(methods, ",") != "PUT,POST" {
+		t.Errorf("Expected PUT then POST, got %v", methods)
+	}
+	if values["/store/todos/1"] != `{"done":false}` {
+		t.Errorf("Expected value to be created, got %q", values["/store/todos/1"])
+	}
+}
+
+func TestUpsertUpdates(t *testing.T) {
+	values := map[string]string{"/store/todos/1": `{"done":false}`}
+	var methods []string
+	c := newTestClient(t, upsertHandler(values, &methods))
+
+	err := c.Upsert("todos/1", map[string]bool{"done": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(methods, ",") != "PUT" {
+		t.Errorf("Expected a single PUT, got %v", methods)
+	}
+	if values["/store/todos/1"] != `{"done":true}` {
+		t.Errorf("Expected value to be updated, got %q", values["/store/todos/1"])
+	}
+}
+
+func TestDeleteIfExists(t *testing.T) {
+	status := http.StatusNotFound
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	err := c.DeleteIfExists("missing")
+	if err != nil {
+		t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+	}
+	err = c.Delete("missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected Delete to return ErrNotFound, got %v", err)
+	}
+
+	status = http.StatusInternalServerError
+	err = c.DeleteIfExists("broken")
+	if code, _ := StatusCode(err); code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 to be returned, got %v", err)
+	}
+}
+
+func TestServerErrorMessages(t *testing.T) {
+	result := `"quota exceeded"`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"result":` + result + `}`))
+	})
+
+	err := c.Put("todos/1", true)
+	if err == nil || !strings.HasSuffix(err.Error(), ": quota exceeded") {
+		t.Errorf("Expected PUT error with server message, got %v", err)
+	}
+	var v interface{}
+	err = c.Get("todos/1", &v)
+	if err == nil || !strings.HasSuffix(err.Error(), ": quota exceeded") {
+		t.Errorf("Expected GET error with server message, got %v", err)
+	}
+
+	result = `{"code":42}`
+	err = c.Put("todos/1", true)
+	if err == nil || strings.Contains(err.Error(), "code") {
+		t.Errorf("Expected PUT error without non string result, got %v", err)
+	}
+}
+
+func TestGetBytesCheckedMissingKey(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, h.ServeHTTP)
+
+	data, err := c.GetBytesChecked("missing")
+	if !errors.Is(err, ErrNoValue) || data != nil {
+		t.Errorf("Expected ErrNoValue without data, got %s, %v", data, err)
+	}
+	raw, err := c.GetBytes("missing")
+	if err != nil || string(raw) != `{"ok":true,"result":null}` {
+		t.Errorf("Expected GetBytes to return the raw response, got %s, %v", raw, err)
+	}
+
+	err = c.Put("todos/1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = c.GetBytesChecked("todos/1")
+	if err != nil || string(data) != `{"ok":true,"result":true}` {
+		t.Errorf("Expected stored response, got %s, %v", data, err)
+	}
+}
+
+func TestDoWithoutHeaders(t *testing.T) {
+	rr := &requestRecorder{result: `"b"`}
+	c := newTestClient(t, rr.ServeHTTP, WithUserAgent("test-agent"))
+
+	resp, err := c.Do(&http.Request{Method: http.MethodGet, URL: &url.URL{Path: "a"}})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+	r := rr.last()
+	if r == nil || r.URL.Path != "/store/a" {
+		t.Fatalf("expected request to /store/a, got %v", r)
+	}
+	if ua := r.Header.Get("User-Agent"); ua != "test-agent" {
+		t.Errorf("expected default headers to be set, got User-Agent %q", ua)
+	}
+}
+
+// faultyHandler counts the requests it serves and fails them with status while failures
+// remain, answering the others with result, or 1 if no result is stored. Its state may be
+// changed while the server runs.
+type faultyHandler struct {
+	requests atomic.Int32
+	failures atomic.Int32
+	status   atomic.Int32
+	result   atomic.Value
+}
+
+// failingHandler returns a faultyHandler failing the first n requests with status.
+func failingHandler(n, status int) *faultyHandler {
+	h := &faultyHandler{}
+	h.failures.Store(int32(n))
+	h.status.Store(int32(status))
+	return h
+}
+
+// failWith makes h fail all following requests with status, or none if status is 0.
+func (h *faultyHandler) failWith(status int) {
+	h.status.Store(int32(status))
+	h.failures.Store(math.MaxInt32)
+}
+
+func (h *faultyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.requests.Add(1)
+	if s := int(h.status.Load()); s != 0 && h.failures.Add(-1) >= 0 {
+		w.WriteHeader(s)
+		return
+	}
+	result, _ := h.result.Load().(string)
+	if result == "" {
+		result = "1"
+	}
+	writeResult(w, result)
+}
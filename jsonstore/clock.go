@@ -0,0 +1,33 @@
+package jsonstore
+
+import "time"
+
+// Clock tells the time and waits for durations to pass. The client uses it to wait
+// between retries, for the deadlines of WithAdaptiveTimeout, to expire responses cached
+// with WithCache and to time requests for the circuit breaker, the adaptive timeout and
+// Retry-After headers, so tests can control time without real sleeping. Timeouts set
+// with WithTimeout, WithDefaultDeadline and the transport options use the real time.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClock makes the client use clock instead of the real time,
+// which is meant for tests. A nil clock keeps the real time.
+func WithClock(clock Clock) Option {
+	return func(c *HttpClient) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
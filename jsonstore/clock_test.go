@@ -0,0 +1,169 @@
+package jsonstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that does not move on its own. Waits started with After
+// advance the clock by their duration and end right away, so that retries run
+// without sleeping, unless the clock is manual, in which case they end once the
+// clock is advanced past them with Advance. Every duration waited for is recorded.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	manual bool
+	waits  []time.Duration
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func newManualClock() *fakeClock {
+	c := newFakeClock()
+	c.manual = true
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	timer := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, timer)
+	if !c.manual {
+		c.now = timer.at
+	}
+	c.fire()
+	return timer.c
+}
+
+// Advance moves the clock forward by d, ending the waits that have passed.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+func (c *fakeClock) fire() {
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.c <- c.now
+	}
+	c.timers = pending
+}
+
+// Waits returns the durations waited for so far.
+func (c *fakeClock) Waits() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+func TestClockControlsRetryBackoff(t *testing.T) {
+	clock := newFakeClock()
+	h := failingHandler(100, http.StatusServiceUnavailable)
+	c := newTestClient(t, h.ServeHTTP, WithRetry(8, time.Second), WithClock(clock))
+
+	start := time.Now()
+	err := c.Get("counter", new(int))
+	if code, _ := StatusCode(err); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected HTTPError with status 503, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected retries to run without sleeping, took %s", elapsed)
+	}
+	waits := clock.Waits()
+	if len(waits) != 7 {
+		t.Fatalf("expected 7 waits, got %v", waits)
+	}
+	delay := time.Second
+	var total time.Duration
+	for i, wait := range waits {
+		limit := delay
+		if limit > maxRetryDelay {
+			limit = maxRetryDelay
+		}
+		if wait < limit/2 || wait > limit {
+			t.Errorf("wait %d: expected delay in [%s, %s], got %s", i+1, limit/2, limit, wait)
+		}
+		total += wait
+		delay *= 2
+	}
+	if elapsed := clock.Now().Sub(newFakeClock().Now()); elapsed != total {
+		t.Errorf("expected the clock to move by %s, moved by %s", total, elapsed)
+	}
+}
+
+func TestClockExpiresCachedResponses(t *testing.T) {
+	clock := newFakeClock()
+	h := failingHandler(0, 0)
+	c := newTestClient(t, h.ServeHTTP, WithCache(time.Minute), WithClock(clock))
+
+	get := func() {
+		err := c.Get("counter", new(int))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	get()
+	clock.Advance(time.Minute)
+	get()
+	if n := h.requests.Load(); n != 1 {
+		t.Fatalf("expected cached response within the ttl, got %d requests", n)
+	}
+	clock.Advance(time.Second)
+	get()
+	if n := h.requests.Load(); n != 2 {
+		t.Fatalf("expected cached response to expire after the ttl, got %d requests", n)
+	}
+}
+
+func TestClockControlsAdaptiveDeadline(t *testing.T) {
+	clock := newManualClock()
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		clock.Advance(2 * time.Second)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			writeResult(w, `1`)
+		}
+	}, WithAdaptiveTimeout(10*time.Millisecond, time.Second), WithClock(clock))
+
+	start := time.Now()
+	err := c.Get("counter", new(int))
+	if !errors.Is(err, context.DeadlineExceeded) || !IsTransient(err) {
+		t.Fatalf("expected transient deadline exceeded error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("expected the request to be cut off by the clock, took %s", elapsed)
+	}
+	if timeout := c.adaptive.timeout(); timeout != time.Second {
+		t.Errorf("expected timeout to stay at the max, got %s", timeout)
+	}
+}
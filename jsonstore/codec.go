@@ -0,0 +1,86 @@
+package jsonstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// Codec marshals the values written to jsonstore and unmarshals the values read from it.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// WithResponseDecoder makes the client read responses with fn instead of expecting the
+// {"result": ..., "ok": ...} envelope of jsonstore, for compatible backends that wrap values
+// differently. fn returns the raw JSON of the stored value, null or nil for missing keys,
+// and whether the request succeeded.
+func WithResponseDecoder(fn func(data []byte) (result json.RawMessage, ok bool, err error)) Option {
+	return func(c *HttpClient) {
+		c.responseDecoder = fn
+	}
+}
+
+func decodeEnvelope(fn func([]byte) (json.RawMessage, bool, error), method, key string, data []byte) (*Response, error) {
+	result, ok, err := fn(data)
+	if err != nil {
+		return nil, newDecodeError(method, key, data, err)
+	}
+	return &Response{Result: result, OK: ok}, nil
+}
+
+// WithCodec makes the client encode and decode values with codec instead of encoding/json,
+// e.g. to use a faster JSON library. The response envelope is always decoded with encoding/json.
+func WithCodec(codec Codec) Option {
+	return func(c *HttpClient) {
+		c.codec = codec
+	}
+}
+
+// WithStrictDecoding makes the client fail to decode values containing object fields
+// that do not exist in the struct decoded into, catching drift between stored data and
+// its Go types. Replaces a codec set with WithCodec and is replaced by it.
+func WithStrictDecoding() Option {
+	return func(c *HttpClient) {
+		c.codec = strictJSONCodec{}
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// strictJSONCodec is encoding/json disallowing unknown fields when decoding.
+type strictJSONCodec struct {
+	jsonCodec
+}
+
+func (strictJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("Unexpected data after JSON value")
+	}
+	return nil
+}
+
+// decodeValue decodes JSON into generic maps, slices and values, keeping numbers
+// as json.Number so that large integers survive being written back.
+func decodeValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	err := dec.Decode(&value)
+	return value, err
+}
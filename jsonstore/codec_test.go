@@ -0,0 +1,100 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// decodeDataEnvelope decodes the {"status": ..., "data": ...} envelope of a variant backend.
+func decodeDataEnvelope(data []byte) (json.RawMessage, bool, error) {
+	var envelope struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+	}
+	err := json.Unmarshal(data, &envelope)
+	return envelope.Data, envelope.Status == "success", err
+}
+
+func TestResponseDecoder(t *testing.T) {
+	responses := map[string]string{
+		"/store/todos/1":  `{"status":"success","data":{"title":"Custom envelope","done":true}}`,
+		"/store/missing":  `{"status":"success","data":null}`,
+		"/store/failing":  `{"status":"error","data":"Something went wrong"}`,
+		"/store/garbled":  `{"status":`,
+		"/store/no-value": `{"status":"success"}`,
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responses[r.URL.Path]))
+	}, WithResponseDecoder(decodeDataEnvelope))
+
+	var todo struct {
+		Title string `json:"title"`
+		Done  bool   `json:"done"`
+	}
+	err := c.Get("todos/1", &todo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.Title != "Custom envelope" || !todo.Done {
+		t.Errorf("Expected decoded todo, got %+v", todo)
+	}
+
+	for _, key := range []string{"missing", "no-value"} {
+		var v interface{}
+		if err := c.Get(key, &v); !errors.Is(err, ErrNoValue) {
+			t.Errorf("%s: expected ErrNoValue, got %v", key, err)
+		}
+	}
+
+	var v interface{}
+	if err := c.Get("failing", &v); err == nil || errors.Is(err, ErrNoValue) {
+		t.Errorf("Expected failed response to return an error, got %v", err)
+	}
+	var decodeErr *DecodeError
+	if err := c.Get("garbled", &v); !errors.As(err, &decodeErr) {
+		t.Errorf("Expected DecodeError, got %v", err)
+	}
+}
+
+func TestDefaultEnvelope(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":1}`))
+	})
+	var v int
+	err := c.Get("todos/1", &v)
+	if !errors.Is(err, ErrNoValue) {
+		t.Errorf("Expected the data envelope to be read as {result, ok} without a response decoder, got %v", err)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	h := newStoreHandler()
+	h.values["/store/todos/1"] = `{"title":"Strict","done":false,"priority":1}`
+	type todo struct {
+		Title string `json:"title"`
+		Done  bool   `json:"done"`
+	}
+
+	var lenient todo
+	err := newTestClient(t, h.ServeHTTP).Get("todos/1", &lenient)
+	if err != nil {
+		t.Errorf("Expected extra field to be ignored by default, got %v", err)
+	}
+	if lenient.Title != "Strict" {
+		t.Errorf("Expected title Strict, got %q", lenient.Title)
+	}
+
+	strict := newTestClient(t, h.ServeHTTP, WithStrictDecoding())
+	var v todo
+	err = strict.Get("todos/1", &v)
+	if err == nil {
+		t.Error("Expected strict decoding to fail on an unknown field")
+	}
+	h.values["/store/todos/2"] = `{"title":"Known","done":true}`
+	err = strict.Get("todos/2", &v)
+	if err != nil || v.Title != "Known" || !v.Done {
+		t.Errorf("Expected known fields to decode, got %+v, %v", v, err)
+	}
+}
@@ -0,0 +1,72 @@
+package jsonstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// WithCompression makes the client gzip the bodies of write requests and ask for gzip
+// encoded responses. Bodies streamed with PostReader and PutReader are sent as is.
+// Responses are only decompressed if the server marks them as gzip encoded, so servers
+// responding uncompressed keep working.
+func WithCompression() Option {
+	return func(c *HttpClient) {
+		c.compress = true
+	}
+}
+
+// newBytesRequest creates a request sending data to key, gzipped if compression is enabled.
+func (c *HttpClient) newBytesRequest(method, key string, data []byte) (*http.Request, error) {
+	if c.compress {
+		return c.newCompressedRequest(method, key, data)
+	}
+	return c.newRequest(method, key, bytes.NewBuffer(data))
+}
+
+func (c *HttpClient) newCompressedRequest(method, key string, data []byte) (*http.Request, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(method, key, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Encoding", "gzip")
+	return req, nil
+}
+
+// decompressResponse replaces the body of a gzip encoded response with a decompressing reader.
+func decompressResponse(resp *http.Response) (*http.Response, error) {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return resp, nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = &gzipBody{Reader: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return resp, nil
+}
+
+type gzipBody struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
@@ -0,0 +1,105 @@
+package jsonstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// gzipHandler records the decoded body and Content-Encoding of the last write and
+// responds with a gzip encoded jsonstore response.
+func gzipHandler(t *testing.T, body *string, encoding *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("%s: Expected Accept-Encoding gzip, got %q", r.Method, r.Header.Get("Accept-Encoding"))
+		}
+		*encoding = r.Header.Get("Content-Encoding")
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if *encoding == "gzip" {
+			zr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("%s: Could not read gzipped body: %v", r.Method, err)
+			}
+			data, _ = ioutil.ReadAll(zr)
+		}
+		*body = string(data)
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(`{"ok":true,"result":{"name":"gzipped"}}`))
+		zw.Close()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+	}
+}
+
+func TestCompressionGzipsWriteBodies(t *testing.T) {
+	var body, encoding string
+	c := newTestClient(t, gzipHandler(t, &body, &encoding), WithCompression())
+
+	writes := map[string]func() error{
+		"PostBytes": func() error { return c.PostBytes("a", []byte(`{"n":1}`)) },
+		"PutBytes":  func() error { return c.PutBytes("a", []byte(`{"n":1}`)) },
+		"Post":      func() error { return c.Post("a", map[string]int{"n": 1}) },
+		"PatchBytes": func() error {
+			return c.PatchBytes("a", []byte(`{"n":1}`))
+		},
+		"BatchPutOne": func() error {
+			_, err := c.BatchPutOne("a", map[string]int{"n": 1})
+			return err
+		},
+	}
+	for name, write := range writes {
+		body, encoding = "", ""
+		err := write()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if encoding != "gzip" {
+			t.Errorf("%s: Expected gzip Content-Encoding, got %q", name, encoding)
+		}
+		if body != `{"n":1}` {
+			t.Errorf("%s: Expected body {\"n\":1}, got %s", name, body)
+		}
+	}
+}
+
+func TestCompressionSendsStreamedBodiesAsIs(t *testing.T) {
+	var body, encoding string
+	c := newTestClient(t, gzipHandler(t, &body, &encoding), WithCompression())
+
+	err := c.PutReader("a", strings.NewReader(`{"n":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoding != "" {
+		t.Errorf("Expected no Content-Encoding, got %q", encoding)
+	}
+	if body != `{"n":1}` {
+		t.Errorf("Expected body {\"n\":1}, got %s", body)
+	}
+}
+
+func TestCompressionDecodesGzipResponses(t *testing.T) {
+	var body, encoding string
+	c := newTestClient(t, gzipHandler(t, &body, &encoding), WithCompression())
+
+	var v struct {
+		Name string `json:"name"`
+	}
+	err := c.Get("a", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "gzipped" {
+		t.Errorf("Expected name gzipped, got %q", v.Name)
+	}
+}
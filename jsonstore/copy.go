@@ -0,0 +1,33 @@
+package jsonstore
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Copy stores the value of srcKey at dstKey as is, without decoding it.
+// Returns ErrNoValue, leaving dstKey untouched, if no value is stored at srcKey.
+func (c *HttpClient) Copy(srcKey, dstKey string) error {
+	value, err := c.GetRaw(srcKey)
+	if errors.Is(err, ErrNotFound) {
+		return ErrNoValue
+	}
+	if err != nil {
+		return err
+	}
+	return c.PostBytes(dstKey, value)
+}
+
+// Move copies the value of srcKey to dstKey with Copy and deletes srcKey
+// once the copy has succeeded. If the delete fails the value is left at both keys.
+func (c *HttpClient) Move(srcKey, dstKey string) error {
+	err := c.Copy(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+	err = c.Delete(srcKey)
+	if err != nil {
+		return fmt.Errorf("Copied '%s' to '%s' but could not delete it: %w", srcKey, dstKey, err)
+	}
+	return nil
+}
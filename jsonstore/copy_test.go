@@ -0,0 +1,105 @@
+package jsonstore_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore"
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore/jsonstoretest"
+)
+
+func TestCopy(t *testing.T) {
+	srv, client := jsonstoretest.NewServer()
+	defer srv.Close()
+
+	err := client.Put("todos/1", todo{Title: "Copy me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Copy("todos/1", "archive/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"todos/1", "archive/1"} {
+		var v todo
+		err = client.Get(key, &v)
+		if err != nil || v.Title != "Copy me" {
+			t.Errorf("%s: expected copied todo, got %+v, %v", key, v, err)
+		}
+	}
+}
+
+func TestCopyMissingSource(t *testing.T) {
+	srv, client := jsonstoretest.NewServer()
+	defer srv.Close()
+
+	err := client.Copy("todos/missing", "archive/1")
+	if !errors.Is(err, jsonstore.ErrNoValue) {
+		t.Errorf("Expected ErrNoValue, got %v", err)
+	}
+	exists, err := client.Exists("archive/1")
+	if err != nil || exists {
+		t.Errorf("Expected destination not to be created, got %t, %v", exists, err)
+	}
+}
+
+func TestMove(t *testing.T) {
+	srv, client := jsonstoretest.NewServer()
+	defer srv.Close()
+
+	err := client.Put("todos/1", todo{Title: "Move me", Done: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Move("todos/1", "archive/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v todo
+	err = client.Get("archive/1", &v)
+	if err != nil || v.Title != "Move me" || !v.Done {
+		t.Errorf("Expected moved todo, got %+v, %v", v, err)
+	}
+	exists, err := client.Exists("todos/1")
+	if err != nil || exists {
+		t.Errorf("Expected source to be deleted, got %t, %v", exists, err)
+	}
+
+	err = client.Move("todos/missing", "archive/2")
+	if !errors.Is(err, jsonstore.ErrNoValue) {
+		t.Errorf("Expected ErrNoValue moving a missing key, got %v", err)
+	}
+}
+
+func TestMoveKeepsSourceWhenCopyFails(t *testing.T) {
+	store := jsonstoretest.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && strings.Contains(r.URL.Path, "/readonly/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		store.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	client, err := jsonstore.NewClientWithURL(srv.URL, jsonstoretest.StoreKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Put("todos/1", todo{Title: "Stay"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Move("todos/1", "readonly/1")
+	if code, _ := jsonstore.StatusCode(err); code != http.StatusForbidden {
+		t.Errorf("Expected 403 from the copy, got %v", err)
+	}
+	var v todo
+	err = client.Get("todos/1", &v)
+	if err != nil || v.Title != "Stay" {
+		t.Errorf("Expected source to be kept, got %+v, %v", v, err)
+	}
+}
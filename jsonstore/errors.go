@@ -0,0 +1,169 @@
+package jsonstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrNoValue is returned when no value is stored at a key.
+	ErrNoValue = errors.New("No value for key")
+	// ErrNotFound is matched by HTTPErrors for 404 responses.
+	ErrNotFound = errors.New("Key not found")
+	// ErrEmptyKey is matched by errors for keys that would address the whole store.
+	ErrEmptyKey = errors.New("Key is empty")
+	// ErrPreconditionFailed is matched by HTTPErrors for 412 responses, returned
+	// by conditional writes such as PutIfMatch when the value has changed.
+	ErrPreconditionFailed = errors.New("Precondition failed")
+	// ErrClientClosed is returned for requests made after Close.
+	ErrClientClosed = errors.New("Client is closed")
+	// ErrResponseTooLarge is returned when a response exceeds WithMaxResponseSize.
+	ErrResponseTooLarge = errors.New("Response body too large")
+	// ErrDecodeResponse is matched by DecodeErrors for responses that cannot be decoded.
+	ErrDecodeResponse = errors.New("Could not decode response")
+	// ErrPayloadTooLarge is returned for request bodies exceeding the limit of MaxBodySize.
+	ErrPayloadTooLarge = errors.New("Request body too large")
+	// ErrCircuitOpen is returned without sending the request while the circuit breaker
+	// set up with WithCircuitBreaker is open.
+	ErrCircuitOpen = errors.New("Circuit breaker is open")
+	// ErrWriterClosed is returned for values posted to an AsyncWriter after Close.
+	ErrWriterClosed = errors.New("Async writer is closed")
+	// ErrServedStale is matched by errors of GetCachedOnError when a cached value,
+	// which may be out of date, was decoded because the request failed.
+	ErrServedStale = errors.New("Served stale value")
+)
+
+// maxErrorBodySize is the number of bytes of a failed response kept in HTTPError.
+const maxErrorBodySize = 4 * 1024
+
+// HTTPError error returned for responses with a non successful status code.
+// Body holds the start of the response body and RetryAfter the delay asked for
+// by a Retry-After header, zero if the response had none. RequestID is set with
+// WithRequestID. A 404 status matches ErrNotFound and a 412 status
+// ErrPreconditionFailed with errors.Is.
+type HTTPError struct {
+	StatusCode int
+	Body       []byte
+	Method     string
+	URL        string
+	RequestID  string
+	RetryAfter time.Duration
+
+	response *http.Response
+}
+
+// StatusError is kept for compatibility, use HTTPError.
+type StatusError = HTTPError
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("Non OK status: %d", e.StatusCode)
+	if e.URL != "" {
+		msg = fmt.Sprintf("%s for %s %s", msg, e.Method, e.URL)
+	}
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s (request id %s)", msg, e.RequestID)
+	}
+	return msg
+}
+
+// Is reports whether the http error matches target.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrPreconditionFailed:
+		return e.StatusCode == http.StatusPreconditionFailed
+	default:
+		return false
+	}
+}
+
+func newHTTPError(resp *http.Response, now time.Time) *HTTPError {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	err := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now),
+	}
+	if resp.Request != nil {
+		err.Method = resp.Request.Method
+		err.URL = resp.Request.URL.String()
+		err.RequestID = requestIDFromContext(resp.Request.Context())
+	}
+	failed := *resp
+	failed.Body = nil
+	err.response = &failed
+	return err
+}
+
+// maxDecodeErrorBodySize is the number of bytes of an undecodable response kept in DecodeError.
+const maxDecodeErrorBodySize = 256
+
+// DecodeError error returned when a response from jsonstore cannot be decoded.
+// Body holds the start of the response body and URL the url the key resolved to,
+// empty if the response was not read by an HttpClient. Matches ErrDecodeResponse with errors.Is.
+type DecodeError struct {
+	Method string
+	Key    string
+	URL    string
+	Body   []byte
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.URL == "" {
+		return fmt.Sprintf("Could not decode response of %s '%s': %s, body: %q", e.Method, e.Key, e.Err, e.Body)
+	}
+	return fmt.Sprintf("Could not decode response of %s '%s' (%s): %s, body: %q", e.Method, e.Key, e.URL, e.Err, e.Body)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the decode error matches target.
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecodeResponse
+}
+
+func newDecodeError(method, key string, body []byte, err error) *DecodeError {
+	if len(body) > maxDecodeErrorBodySize {
+		body = body[:maxDecodeErrorBodySize]
+	}
+	return &DecodeError{
+		Method: method,
+		Key:    key,
+		Body:   copyBytes(body),
+		Err:    err,
+	}
+}
+
+// IsNotFound reports whether err means that nothing is stored at a key,
+// either ErrNoValue or a 404 response matching ErrNotFound.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNoValue) || errors.Is(err, ErrNotFound)
+}
+
+// IsTransient reports whether err is likely to go away when retried later: a network
+// error or timeout, a 429 or 5xx response, or ErrCircuitOpen.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, ErrCircuitOpen) || isRetryable(err)
+}
+
+// StatusCode returns the status code of the response err was returned for,
+// reporting false if err does not carry a response.
+func StatusCode(err error) (int, bool) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode, true
+	}
+	return 0, false
+}
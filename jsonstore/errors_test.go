@@ -0,0 +1,80 @@
+package jsonstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func wrap(err error) error {
+	return fmt.Errorf("Could not load todos: %w", fmt.Errorf("Request failed: %w", err))
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := map[error]bool{
+		nil:              false,
+		ErrNoValue:       true,
+		wrap(ErrNoValue): true,
+		wrap(&HTTPError{StatusCode: http.StatusNotFound}):            true,
+		wrap(&HTTPError{StatusCode: http.StatusInternalServerError}): false,
+		wrap(ErrEmptyKey):      false,
+		errors.New("No value"): false,
+	}
+	for err, expected := range tests {
+		if IsNotFound(err) != expected {
+			t.Errorf("IsNotFound(%v): expected %t", err, expected)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	netErr := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	tests := map[error]bool{
+		nil:                  false,
+		wrap(netErr):         true,
+		wrap(ErrCircuitOpen): true,
+		wrap(&HTTPError{StatusCode: http.StatusTooManyRequests}):    true,
+		wrap(&HTTPError{StatusCode: http.StatusServiceUnavailable}): true,
+		wrap(&HTTPError{StatusCode: http.StatusBadRequest}):         false,
+		wrap(&HTTPError{StatusCode: http.StatusNotFound}):           false,
+		wrap(ErrClientClosed):    false,
+		wrap(ErrPayloadTooLarge): false,
+		wrap(ErrNoValue):         false,
+		wrap(context.Canceled):   false,
+	}
+	for err, expected := range tests {
+		if IsTransient(err) != expected {
+			t.Errorf("IsTransient(%v): expected %t", err, expected)
+		}
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	code, ok := StatusCode(wrap(&HTTPError{StatusCode: http.StatusConflict}))
+	if !ok || code != http.StatusConflict {
+		t.Errorf("Expected 409, got %d, %t", code, ok)
+	}
+	code, ok = StatusCode(wrap(ErrNoValue))
+	if ok || code != 0 {
+		t.Errorf("Expected no status code, got %d, %t", code, ok)
+	}
+	code, ok = StatusCode(nil)
+	if ok || code != 0 {
+		t.Errorf("Expected no status code for nil, got %d, %t", code, ok)
+	}
+}
+
+func TestHTTPErrorMatchesSentinels(t *testing.T) {
+	err := wrap(&HTTPError{StatusCode: http.StatusPreconditionFailed})
+	if !errors.Is(err, ErrPreconditionFailed) || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected 412 to match only ErrPreconditionFailed, got %v", err)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("Expected to find the HTTPError in %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package jsonstore
+
+import (
+	"errors"
+	"net/http"
+)
+
+// GetWithETag gets a value from jsonstore like Get and returns the ETag of the response,
+// which is empty if the server does not send one. The response is never served from the cache.
+func (c *HttpClient) GetWithETag(key string, v interface{}) (string, error) {
+	req, err := c.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return "", err
+	}
+	rawResponse, header, err := c.readResponse(req)
+	if err != nil {
+		return "", err
+	}
+	return header.Get("ETag"), c.decodeResult(key, rawResponse, v)
+}
+
+// GetIfChanged gets a value from jsonstore like GetWithETag unless its ETag still matches etag,
+// in which case the server responds with 304 Not Modified, v is left untouched and changed is
+// false. An empty etag always gets the value. Returns the current ETag of the value.
+func (c *HttpClient) GetIfChanged(key, etag string, v interface{}) (string, bool, error) {
+	client := c
+	if etag != "" {
+		client = c.With(WithRequestHeader("If-None-Match", etag))
+	}
+	req, err := client.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return "", false, err
+	}
+	rawResponse, header, err := client.readResponse(req)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotModified {
+		return etag, false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	err = c.decodeResult(key, rawResponse, v)
+	if err != nil {
+		return "", false, err
+	}
+	return header.Get("ETag"), true, nil
+}
+
+// PutIfMatch updates the value of a key only if its current ETag matches etag,
+// as returned by GetWithETag. Returns an error matching ErrPreconditionFailed
+// if the value has been changed since.
+func (c *HttpClient) PutIfMatch(key string, v interface{}, etag string) error {
+	body, err := c.marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = c.With(WithRequestHeader("If-Match", etag)).sendBytes(http.MethodPut, key, body)
+	return err
+}
@@ -0,0 +1,73 @@
+package jsonstore
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// etagHandler serves value with etag, answering 304 Not Modified to requests whose
+// If-None-Match matches it, and records the If-None-Match headers it receives.
+type etagHandler struct {
+	mu          sync.Mutex
+	value       string
+	etag        string
+	ifNoneMatch []string
+}
+
+func (h *etagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ifNoneMatch = append(h.ifNoneMatch, r.Header.Get("If-None-Match"))
+	w.Header().Set("ETag", h.etag)
+	if r.Header.Get("If-None-Match") == h.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeResult(w, h.value)
+}
+
+func (h *etagHandler) set(value, etag string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.value, h.etag = value, etag
+}
+
+func TestGetIfChanged(t *testing.T) {
+	h := &etagHandler{value: `"first"`, etag: `"v1"`}
+	c := newTestClient(t, h.ServeHTTP)
+
+	var v string
+	etag, changed, err := c.GetIfChanged("greeting", "", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || etag != `"v1"` || v != "first" {
+		t.Errorf("Expected first get to return \"first\" with ETag \"v1\", got %q, %s, changed %t", v, etag, changed)
+	}
+
+	v = "untouched"
+	etag, changed, err = c.GetIfChanged("greeting", etag, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || etag != `"v1"` || v != "untouched" {
+		t.Errorf("Expected 304 to leave value untouched, got %q, %s, changed %t", v, etag, changed)
+	}
+
+	h.set(`"second"`, `"v2"`)
+	etag, changed, err = c.GetIfChanged("greeting", etag, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || etag != `"v2"` || v != "second" {
+		t.Errorf("Expected changed value \"second\" with ETag \"v2\", got %q, %s, changed %t", v, etag, changed)
+	}
+
+	expected := []string{"", `"v1"`, `"v1"`}
+	for i, header := range h.ifNoneMatch {
+		if header != expected[i] {
+			t.Errorf("Request %d: expected If-None-Match %q, got %q", i, expected[i], header)
+		}
+	}
+}
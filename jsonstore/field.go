@@ -0,0 +1,30 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// GetField gets the value at key and unmarshals the field at fieldPath of it into v.
+// fieldPath is a dotted path of object fields and array indices, e.g. "meta.created"
+// or "tags.0". Returns ErrNoValue if the field path does not resolve to a value.
+func (c *HttpClient) GetField(key, fieldPath string, v interface{}) error {
+	var raw json.RawMessage
+	err := c.Get(key, &raw)
+	if err != nil {
+		return err
+	}
+	value, err := decodeValue(raw)
+	if err != nil {
+		return err
+	}
+	field := lookupPath(value, strings.Split(fieldPath, "."))
+	if field == nil {
+		return ErrNoValue
+	}
+	data, err := json.Marshal(field)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(data, v)
+}
@@ -0,0 +1,29 @@
+package jsonstore
+
+import "net/http"
+
+// IdempotencyKeyHeader is the header carrying the key of requests set up with WithIdempotency.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotency sends a random key in the Idempotency-Key header of every POST and PATCH
+// request and retries them like idempotent requests, see WithRetry. The key is generated
+// once per call, such as PostBytes, and sent with all of its retries, so a server that
+// supports idempotency keys stores the value only once even if an earlier attempt reached it.
+func WithIdempotency() Option {
+	return func(c *HttpClient) {
+		c.idempotency = true
+	}
+}
+
+// setIdempotencyKey sets a new idempotency key on req if it needs one.
+func (c *HttpClient) setIdempotencyKey(req *http.Request) {
+	if !c.idempotency || isIdempotent(req.Method) {
+		return
+	}
+	req.Header.Set(IdempotencyKeyHeader, newUUID())
+}
+
+// hasIdempotencyKey reports whether req can be retried safely because of its idempotency key.
+func hasIdempotencyKey(req *http.Request) bool {
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
@@ -0,0 +1,83 @@
+package jsonstore
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// keyRecorder fails the first n requests with 503 and records their Idempotency-Key headers.
+type keyRecorder struct {
+	mu   sync.Mutex
+	n    int
+	keys []string
+}
+
+func (kr *keyRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	kr.mu.Lock()
+	kr.keys = append(kr.keys, r.Header.Get(IdempotencyKeyHeader))
+	failed := len(kr.keys) <= kr.n
+	kr.mu.Unlock()
+	if failed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	writeResult(w, `null`)
+}
+
+func TestIdempotencyKeyIsStableAcrossRetries(t *testing.T) {
+	kr := &keyRecorder{n: 2}
+	c := newTestClient(t, kr.ServeHTTP, WithIdempotency(), WithRetry(3, time.Second), WithClock(newFakeClock()))
+
+	err := c.Post("todos/1", "write tests")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(kr.keys) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(kr.keys))
+	}
+	if kr.keys[0] == "" {
+		t.Fatal("expected Idempotency-Key header to be set")
+	}
+	for i, key := range kr.keys {
+		if key != kr.keys[0] {
+			t.Errorf("attempt %d: expected key %s, got %s", i+1, kr.keys[0], key)
+		}
+	}
+
+	err = c.Post("todos/2", "write more tests")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := kr.keys[len(kr.keys)-1]; last == "" || last == kr.keys[0] {
+		t.Errorf("expected a new key for the next Post, got %q", last)
+	}
+}
+
+func TestIdempotencyKeyOnlyForNonIdempotentMethods(t *testing.T) {
+	kr := &keyRecorder{}
+	c := newTestClient(t, kr.ServeHTTP, WithIdempotency())
+
+	c.Put("todos/1", "write tests")
+	c.Delete("todos/1")
+	c.Get("todos/1", new(string))
+	for i, key := range kr.keys {
+		if key != "" {
+			t.Errorf("request %d: expected no Idempotency-Key header, got %s", i+1, key)
+		}
+	}
+}
+
+func TestPostNotRetriedWithoutIdempotency(t *testing.T) {
+	kr := &keyRecorder{n: 1}
+	c := newTestClient(t, kr.ServeHTTP, WithRetry(3, time.Second), WithClock(newFakeClock()))
+
+	err := c.Post("todos/1", "write tests")
+	if code, _ := StatusCode(err); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected HTTPError with status 503, got %v", err)
+	}
+	if len(kr.keys) != 1 || kr.keys[0] != "" {
+		t.Errorf("expected a single attempt without Idempotency-Key, got %q", kr.keys)
+	}
+}
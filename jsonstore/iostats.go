@@ -0,0 +1,72 @@
+package jsonstore
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+type ioStats struct {
+	sent     int64
+	received int64
+}
+
+// LastIO returns the number of bytes of the body sent with the last request of the client
+// and of the body received in response, as transferred, before any decompression. The
+// received bytes are counted as the response is read, so they are complete once its body
+// is closed, which all methods of the client do before returning.
+func (c *HttpClient) LastIO() (sent, received int64) {
+	stats := c.lastIO.Load()
+	if stats == nil {
+		return 0, 0
+	}
+	return stats.sent, stats.received
+}
+
+// countRequest counts the bytes read from the body of r as it is sent.
+func countRequest(r *http.Request) *countingBody {
+	body := &countingBody{}
+	if r.Body != nil && r.Body != http.NoBody {
+		body.ReadCloser = r.Body
+		r.Body = body
+	}
+	return body
+}
+
+// countResponse counts the bytes read from the body of resp and records them, together
+// with the bytes sent, as the last IO of the client once the body is closed.
+func (c *HttpClient) countResponse(sent *countingBody, resp *http.Response) {
+	resp.Body = &countingBody{
+		ReadCloser: resp.Body,
+		onClose: func(received int64) {
+			c.lastIO.Store(&ioStats{sent: sent.count(), received: received})
+		},
+	}
+}
+
+// countingBody counts the bytes read from a body, calling onClose with the count once closed.
+type countingBody struct {
+	io.ReadCloser
+	n       atomic.Int64
+	onClose func(n int64)
+	once    sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.onClose != nil {
+		b.once.Do(func() { b.onClose(b.n.Load()) })
+	}
+	return err
+}
+
+func (b *countingBody) count() int64 {
+	return b.n.Load()
+}
@@ -0,0 +1,18 @@
+module github.com/CzarSimon/jsonstore-go-client/jsonstore/jsonstoreotel
+
+go 1.20
+
+require (
+	github.com/CzarSimon/jsonstore-go-client v0.0.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/time v0.5.0 // indirect
+)
+
+replace github.com/CzarSimon/jsonstore-go-client => ../..
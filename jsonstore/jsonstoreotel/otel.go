@@ -0,0 +1,65 @@
+// Package jsonstoreotel adds OpenTelemetry tracing to the jsonstore client.
+// It is a separate module so that users of the jsonstore package do not depend on OpenTelemetry.
+package jsonstoreotel
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/CzarSimon/jsonstore-go-client/jsonstore"
+
+// WithTracerProvider makes the client start a span from tp for each request sent to jsonstore,
+// with the method, key, status code and duration as attributes. The span is started from the
+// context of the request and propagated to the server with the global text map propagator.
+func WithTracerProvider(tp trace.TracerProvider) jsonstore.Option {
+	return jsonstore.WithRoundTripper(Middleware(tp))
+}
+
+// Middleware returns a jsonstore.Middleware tracing requests with spans from tp.
+func Middleware(tp trace.TracerProvider) jsonstore.Middleware {
+	tracer := tp.Tracer(instrumentationName)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &tracingTransport{tracer: tracer, next: next}
+	}
+}
+
+type tracingTransport struct {
+	tracer trace.Tracer
+	next   http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	ctx, span := t.tracer.Start(r.Context(), "jsonstore "+r.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.url", r.URL.String()),
+			attribute.String("jsonstore.key", jsonstore.RequestKey(r)),
+		),
+	)
+	defer span.End()
+
+	r = r.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+	resp, err := t.next.RoundTrip(r)
+	span.SetAttributes(attribute.Int64("jsonstore.duration_ms", time.Since(start).Milliseconds()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,76 @@
+// Package jsonstoreprom adds Prometheus metrics to the jsonstore client.
+// It is a separate module so that users of the jsonstore package do not depend on Prometheus.
+//
+// The following metrics are exported:
+//
+//	jsonstore_requests_total{method,status}      counter of requests sent to jsonstore, status is
+//	                                             the response status code or "error" if no response was received
+//	jsonstore_request_duration_seconds{method}   histogram of request latencies
+package jsonstoreprom
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WithMetrics makes the client record metrics for each request in registerer.
+// Several clients can be instrumented with the same registerer.
+func WithMetrics(registerer prometheus.Registerer) jsonstore.Option {
+	return jsonstore.WithRoundTripper(Middleware(registerer))
+}
+
+// Middleware returns a jsonstore.Middleware recording request metrics in registerer.
+func Middleware(registerer prometheus.Registerer) jsonstore.Middleware {
+	requests := register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jsonstore_requests_total",
+		Help: "Number of requests sent to jsonstore by method and status.",
+	}, []string{"method", "status"}))
+	durations := register(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "jsonstore_request_duration_seconds",
+		Help:    "Latency of requests sent to jsonstore by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"}))
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &metricsTransport{
+			requests:  requests,
+			durations: durations,
+			next:      next,
+		}
+	}
+}
+
+type metricsTransport struct {
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+	next      http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(r)
+	t.durations.WithLabelValues(r.Method).Observe(time.Since(start).Seconds())
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	t.requests.WithLabelValues(r.Method, status).Inc()
+	return resp, err
+}
+
+// register registers c, returning the collector already registered under the same name if there is one.
+func register[T prometheus.Collector](registerer prometheus.Registerer, c T) T {
+	err := registerer.Register(c)
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+			return existing
+		}
+	}
+	return c
+}
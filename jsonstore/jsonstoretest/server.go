@@ -0,0 +1,103 @@
+// Package jsonstoretest provides a jsonstore compatible server for testing code that uses jsonstore.
+package jsonstoretest
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore"
+)
+
+// StoreKey the store key used by clients returned from NewServer.
+const StoreKey = "jsonstoretest"
+
+// NewServer starts a server speaking the jsonstore protocol and returns it together with
+// a client pointed at it. The caller should call Close on the server when finished.
+func NewServer() (*httptest.Server, *jsonstore.HttpClient) {
+	srv := httptest.NewServer(NewHandler())
+	client, err := jsonstore.NewClientWithURL(srv.URL, StoreKey)
+	if err != nil {
+		srv.Close()
+		panic(err)
+	}
+	return srv, client
+}
+
+// NewHandler creates an http.Handler that stores values in memory and wraps
+// responses in the jsonstore {"result", "ok"} envelope. Values are addressed by the
+// full request path, so nested keys and separate store keys work as in jsonstore.
+// Responses to GET carry an ETag, and writes with an If-Match header that does not
+// match the current value are rejected with 412 Precondition Failed.
+func NewHandler() http.Handler {
+	return &handler{store: jsonstore.NewMemoryClient()}
+}
+
+type handler struct {
+	mu    sync.Mutex
+	store *jsonstore.MemoryClient
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		data, err := h.store.GetBytes(key)
+		if err != nil {
+			writeResponse(w, http.StatusInternalServerError, err.Error(), false)
+			return
+		}
+		tag := etag(data)
+		w.Header().Set("ETag", tag)
+		if r.Header.Get("If-None-Match") == tag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	case http.MethodPost, http.MethodPut:
+		if match := r.Header.Get("If-Match"); match != "" {
+			current, err := h.store.GetBytes(key)
+			if err != nil || etag(current) != match {
+				writeResponse(w, http.StatusPreconditionFailed, "Value has changed", false)
+				return
+			}
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = h.store.PostBytes(key, data)
+		}
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, err.Error(), false)
+			return
+		}
+		writeResponse(w, http.StatusOK, nil, true)
+	case http.MethodDelete:
+		h.store.Delete(key)
+		writeResponse(w, http.StatusOK, nil, true)
+	default:
+		writeResponse(w, http.StatusMethodNotAllowed, "Method not allowed", false)
+	}
+}
+
+// etag returns a strong ETag for a stored response.
+func etag(data []byte) string {
+	return fmt.Sprintf("\"%x\"", sha1.Sum(data))
+}
+
+func writeResponse(w http.ResponseWriter, status int, result interface{}, ok bool) {
+	data, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(jsonstore.Response{
+		Result: data,
+		OK:     ok,
+	})
+}
@@ -0,0 +1,88 @@
+package jsonstore
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// ValidateKey checks that key can be used to address a value in jsonstore.
+// Keys must not be empty and must not contain control characters or '.' and '..'
+// segments, '/' separates the segments of nested keys. Leading slashes are ignored.
+// Keys made up of only slashes and whitespace fail with an error matching ErrEmptyKey,
+// as they would address the whole store.
+func ValidateKey(key string) error {
+	if strings.TrimSpace(strings.Trim(key, "/ ")) == "" {
+		return fmt.Errorf("Invalid key '%s': %w", key, ErrEmptyKey)
+	}
+	for _, r := range key {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("Invalid key %q: contains control characters", key)
+		}
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("Invalid key '%s': contains '%s' segment", key, segment)
+		}
+	}
+	return nil
+}
+
+// validateStoreKey checks that storeKey is not empty and only contains
+// characters that are URL safe without escaping.
+func validateStoreKey(storeKey string) error {
+	if strings.TrimSpace(storeKey) == "" {
+		return fmt.Errorf("Invalid store key '%s': %w", storeKey, ErrEmptyKey)
+	}
+	for _, r := range storeKey {
+		if !isUnreserved(r) {
+			return fmt.Errorf("Invalid store key %q: contains %q", storeKey, r)
+		}
+	}
+	return nil
+}
+
+// isUnreserved reports whether r may appear in a URL unescaped, as defined by RFC 3986.
+func isUnreserved(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+		r == '-' || r == '.' || r == '_' || r == '~'
+}
+
+// validateKey checks key with ValidateKey and that it does not start with the store key,
+// which createURL would add a second time.
+func (c *HttpClient) validateKey(key string) error {
+	err := ValidateKey(key)
+	if err != nil {
+		return err
+	}
+	first := strings.SplitN(strings.TrimLeft(key, "/"), "/", 2)[0]
+	if c.storeKey != "" && first == c.storeKey {
+		return fmt.Errorf("Invalid key '%s': starts with the store key, keys are relative to the store", key)
+	}
+	return nil
+}
+
+// ResolveURL returns the url that key is sent to, without making a request.
+// Useful to check how keys are joined with the base url and store key.
+func (c *HttpClient) ResolveURL(key string) string {
+	return c.createURL(key)
+}
+
+// createURL returns the url of key in the store, escaping each segment of the key.
+func (c *HttpClient) createURL(key string) string {
+	key = strings.TrimLeft(key, "/")
+	u := *c.baseURL
+	u.RawPath = path.Join(u.EscapedPath(), escapeKey(key))
+	u.Path = path.Join(u.Path, key)
+	return u.String()
+}
+
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
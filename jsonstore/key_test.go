@@ -0,0 +1,172 @@
+package jsonstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeysAreEscaped(t *testing.T) {
+	tests := []struct {
+		key         string
+		escapedPath string
+		path        string
+	}{
+		{"todos/my todo", "/store/todos/my%20todo", "/store/todos/my todo"},
+		{"what?", "/store/what%3F", "/store/what?"},
+		{"a#b", "/store/a%23b", "/store/a#b"},
+		{"100%", "/store/100%25", "/store/100%"},
+		{"a/b&c=d/e+f", "/store/a/b&c=d/e+f", "/store/a/b&c=d/e+f"},
+		{"ümlaut", "/store/%C3%BCmlaut", "/store/ümlaut"},
+	}
+	rr := &requestRecorder{result: "1"}
+	c := newTestClient(t, rr.ServeHTTP)
+	for _, test := range tests {
+		var v int
+		err := c.Get(test.key, &v)
+		if err != nil {
+			t.Errorf("%q: %v", test.key, err)
+			continue
+		}
+		r := rr.last()
+		if r.URL.EscapedPath() != test.escapedPath {
+			t.Errorf("%q: expected escaped path %s, got %s", test.key, test.escapedPath, r.URL.EscapedPath())
+		}
+		if r.URL.Path != test.path {
+			t.Errorf("%q: expected path %s, got %s", test.key, test.path, r.URL.Path)
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("%q: expected no query, got %s", test.key, r.URL.RawQuery)
+		}
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	valid := []string{"todos", "todos/1", "/todos", "my todo", "what?", "a#b", "100%", "..todos", "a.b"}
+	for _, key := range valid {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("%q: expected valid key, got %v", key, err)
+		}
+	}
+	invalid := []string{"", " ", "/", "//", "todos/../secrets", "./todos", "todos/.", "a\nb", "a\x00b"}
+	for _, key := range invalid {
+		if err := ValidateKey(key); err == nil {
+			t.Errorf("%q: expected invalid key", key)
+		}
+	}
+	for _, key := range []string{"", " ", "/ /"} {
+		if err := ValidateKey(key); !errors.Is(err, ErrEmptyKey) {
+			t.Errorf("%q: expected ErrEmptyKey, got %v", key, err)
+		}
+	}
+}
+
+func TestInvalidKeysAreNotSent(t *testing.T) {
+	rr := &requestRecorder{}
+	c := newTestClient(t, rr.ServeHTTP)
+	var v interface{}
+	err := c.Get("todos/../secrets", &v)
+	if err == nil {
+		t.Error("Expected error for key with '..' segment")
+	}
+	if len(rr.paths()) != 0 {
+		t.Errorf("Expected no requests, got %v", rr.paths())
+	}
+}
+
+func TestCreateURL(t *testing.T) {
+	c, err := NewClientWithURL("https://example.com/base", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"todos":     "https://example.com/base/store/todos",
+		"/todos":    "https://example.com/base/store/todos",
+		"//todos/1": "https://example.com/base/store/todos/1",
+		"todos/1/":  "https://example.com/base/store/todos/1",
+		"":          "https://example.com/base/store",
+		"/":         "https://example.com/base/store",
+	}
+	for key, expected := range tests {
+		if u := c.createURL(key); u != expected {
+			t.Errorf("%q: expected %s, got %s", key, expected, u)
+		}
+	}
+}
+
+func TestKeysAreRejectedBeforeJoining(t *testing.T) {
+	rr := &requestRecorder{}
+	c := newTestClient(t, rr.ServeHTTP)
+	keys := []string{"", "/", "..", "../other-store/todos", "todos/../../other-store", "store/todos", "/store"}
+	for _, key := range keys {
+		var v interface{}
+		if err := c.Get(key, &v); err == nil {
+			t.Errorf("%q: expected key to be rejected", key)
+		}
+		if err := c.Put(key, 1); err == nil {
+			t.Errorf("%q: expected key to be rejected on PUT", key)
+		}
+	}
+	if len(rr.paths()) != 0 {
+		t.Errorf("Expected no requests, got %v", rr.paths())
+	}
+}
+
+func TestEmptyKeyIsRejected(t *testing.T) {
+	rr := &requestRecorder{}
+	c := newTestClient(t, rr.ServeHTTP)
+	for _, key := range []string{"", "  ", "/", " / "} {
+		var v interface{}
+		verbs := map[string]error{
+			"Get":    c.Get(key, &v),
+			"Post":   c.Post(key, 1),
+			"Put":    c.Put(key, 1),
+			"Delete": c.Delete(key),
+		}
+		for verb, err := range verbs {
+			if !errors.Is(err, ErrEmptyKey) {
+				t.Errorf("%s(%q): expected ErrEmptyKey, got %v", verb, key, err)
+			}
+		}
+	}
+	if len(rr.paths()) != 0 {
+		t.Errorf("Expected no requests, got %v", rr.paths())
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		prefix   string
+		key      string
+		expected string
+	}{
+		{"https://example.com", "api/v1", "todos", "https://example.com/api/v1/store/todos"},
+		{"https://example.com", "/api/v1/", "todos", "https://example.com/api/v1/store/todos"},
+		{"https://example.com/", "//api//v1", "/todos/1", "https://example.com/api/v1/store/todos/1"},
+		{"https://example.com/base", "api/v1", "todos", "https://example.com/base/api/v1/store/todos"},
+		{"https://example.com/base/", "/api", "todos/", "https://example.com/base/api/store/todos"},
+		{"https://example.com", "", "todos", "https://example.com/store/todos"},
+		{"https://example.com", "/", "todos", "https://example.com/store/todos"},
+		{"https://example.com", "api v1", "my todo", "https://example.com/api%20v1/store/my%20todo"},
+	}
+	for _, test := range tests {
+		c, err := NewClientWithURL(test.baseURL, "store", WithPathPrefix(test.prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u := c.createURL(test.key); u != test.expected {
+			t.Errorf("%s + %q + %q: expected %s, got %s", test.baseURL, test.prefix, test.key, test.expected, u)
+		}
+	}
+}
+
+func TestPathPrefixWithStoreKey(t *testing.T) {
+	c, err := NewClientWithURL("https://example.com", "store", WithPathPrefix("/api/v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "https://example.com/api/v1/other/todos"
+	if u := c.WithStoreKey("other").createURL("todos"); u != expected {
+		t.Errorf("Expected %s, got %s", expected, u)
+	}
+}
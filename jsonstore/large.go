@@ -0,0 +1,128 @@
+package jsonstore
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+)
+
+const (
+	defaultLargeChunkSize = 32 * 1024
+	largeChunksKey        = "_chunks"
+)
+
+// WithLargeChunkSize sets the maximum size in bytes of each chunk stored by PutLarge,
+// including the quotes of the stored string. Defaults to 32 KiB when n <= 0, other sizes
+// below 6 are raised to 6 so that each chunk holds at least one encoded byte.
+func WithLargeChunkSize(n int) Option {
+	return func(c *HttpClient) {
+		c.largeChunkSize = n
+	}
+}
+
+// largeManifest is the value stored at the key of a value written by PutLarge.
+// The marshaled value is split into chunks that are base64 encoded, so that escaping
+// cannot grow them past the chunk size, and stored as strings at key/_chunks/0..N-1:
+//
+//	{"chunks": 2, "size": 40000, "_chunks": {"0": "eyJ0b2RvcyI6IFsu...", "1": "...XX0="}}
+//
+// As jsonstore nests the chunks inside the value at key, GetLarge reads the fields
+// of the manifest and each chunk with separate requests rather than reading key.
+type largeManifest struct {
+	Chunks int `json:"chunks"`
+	Size   int `json:"size"`
+}
+
+// PutLarge stores a value that may exceed the size jsonstore accepts for a single key
+// by splitting it into chunks. The manifest is written first, replacing any previous
+// value at key, so readers may see missing chunks until PutLarge returns.
+func (c *HttpClient) PutLarge(key string, v interface{}) error {
+	data, err := c.marshal(v)
+	if err != nil {
+		return err
+	}
+	chunks := splitChunks(data, c.largeChunkSize)
+	manifest := largeManifest{
+		Chunks: len(chunks),
+		Size:   len(data),
+	}
+	err = c.postPart(key, manifest)
+	if err != nil {
+		return err
+	}
+	for i, chunk := range chunks {
+		err = c.postPart(chunkKey(key, i), base64.StdEncoding.EncodeToString(chunk))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLarge reassembles a value stored with PutLarge and unmarshals it into v.
+// Each chunk is read with its own request, so no response is larger than a chunk.
+func (c *HttpClient) GetLarge(key string, v interface{}) error {
+	var manifest largeManifest
+	err := c.Get(path.Join(key, "chunks"), &manifest.Chunks)
+	if err != nil {
+		return err
+	}
+	err = c.Get(path.Join(key, "size"), &manifest.Size)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, 0, manifest.Size)
+	for i := 0; i < manifest.Chunks; i++ {
+		var chunk string
+		err = c.Get(chunkKey(key, i), &chunk)
+		if errors.Is(err, ErrNoValue) {
+			return fmt.Errorf("Missing chunk %d of '%s'", i, key)
+		}
+		if err != nil {
+			return err
+		}
+		decoded, err := base64.StdEncoding.DecodeString(chunk)
+		if err != nil {
+			return fmt.Errorf("Invalid chunk %d of '%s': %w", i, key, err)
+		}
+		data = append(data, decoded...)
+	}
+	if len(data) != manifest.Size {
+		return fmt.Errorf("Size mismatch for '%s', expected %d bytes got %d", key, manifest.Size, len(data))
+	}
+	return c.codec.Unmarshal(data, v)
+}
+
+// postPart posts the manifest or a chunk of a large value, which are not validated
+// as the value itself already was.
+func (c *HttpClient) postPart(key string, v interface{}) error {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.PostBytes(key, data)
+}
+
+func chunkKey(key string, index int) string {
+	return path.Join(key, largeChunksKey, strconv.Itoa(index))
+}
+
+// splitChunks splits data into chunks whose base64 encoding, quoted as a JSON
+// string, is at most size bytes.
+func splitChunks(data []byte, size int) [][]byte {
+	if size <= 0 {
+		size = defaultLargeChunkSize
+	}
+	raw := (size - 2) / 4 * 3
+	if raw < 3 {
+		raw = 3
+	}
+	chunks := make([][]byte, 0, len(data)/raw+1)
+	for len(data) > raw {
+		chunks = append(chunks, data[:raw])
+		data = data[raw:]
+	}
+	return append(chunks, data)
+}
@@ -0,0 +1,139 @@
+package jsonstore_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore"
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore/jsonstoretest"
+)
+
+// sizeRecorder records the paths of GET requests and the size of the largest response.
+type sizeRecorder struct {
+	http.ResponseWriter
+	mu      *sync.Mutex
+	largest *int
+	written int
+}
+
+func (w *sizeRecorder) Write(p []byte) (int, error) {
+	w.written += len(p)
+	w.mu.Lock()
+	if w.written > *w.largest {
+		*w.largest = w.written
+	}
+	w.mu.Unlock()
+	return w.ResponseWriter.Write(p)
+}
+
+type todo struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+func TestPutLargeRoundTrip(t *testing.T) {
+	const chunkSize = 256
+	var mu sync.Mutex
+	var largest int
+	var gets []string
+	var chunkBodies [][]byte
+	h := jsonstoretest.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/_chunks/") {
+			body, _ := ioutil.ReadAll(r.Body)
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			mu.Lock()
+			chunkBodies = append(chunkBodies, body)
+			mu.Unlock()
+		}
+		if r.Method == http.MethodGet {
+			mu.Lock()
+			gets = append(gets, r.URL.Path)
+			mu.Unlock()
+			w = &sizeRecorder{ResponseWriter: w, mu: &mu, largest: &largest}
+		}
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	c, err := jsonstore.NewClientWithURL(srv.URL, jsonstoretest.StoreKey, jsonstore.WithLargeChunkSize(chunkSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todos := make([]todo, 100)
+	for i := range todos {
+		todos[i] = todo{Title: strings.Repeat("Écrire des tests 日本 \"quoted\" ", 2), Done: i%2 == 0}
+	}
+	err = c.PutLarge("todos", todos)
+	if err != nil {
+		t.Fatalf("PutLarge: %v", err)
+	}
+	var chunks int
+	err = c.Get("todos/chunks", &chunks)
+	if err != nil || chunks < 10 {
+		t.Fatalf("expected value to be split into many chunks, got %d, %v", chunks, err)
+	}
+	if len(chunkBodies) != chunks {
+		t.Fatalf("expected %d chunks to be posted, got %d", chunks, len(chunkBodies))
+	}
+	for i, body := range chunkBodies {
+		if len(body) > chunkSize {
+			t.Errorf("chunk %d: expected at most %d bytes, got %d", i, chunkSize, len(body))
+		}
+	}
+
+	mu.Lock()
+	gets, largest = nil, 0
+	mu.Unlock()
+	var read []todo
+	err = c.GetLarge("todos", &read)
+	if err != nil {
+		t.Fatalf("GetLarge: %v", err)
+	}
+	if len(read) != len(todos) {
+		t.Fatalf("expected %d todos, got %d", len(todos), len(read))
+	}
+	for i := range todos {
+		if read[i] != todos[i] {
+			t.Fatalf("todo %d: expected %+v, got %+v", i, todos[i], read[i])
+		}
+	}
+	for _, p := range gets {
+		if p == "/"+jsonstoretest.StoreKey+"/todos" {
+			t.Errorf("expected GetLarge not to read the whole value at %s", p)
+		}
+	}
+	if limit := chunkSize + 64; largest > limit {
+		t.Errorf("expected responses of at most about a chunk, largest was %d bytes", largest)
+	}
+}
+
+func TestGetLargeMissingChunk(t *testing.T) {
+	srv, _ := jsonstoretest.NewServer()
+	defer srv.Close()
+	c, err := jsonstore.NewClientWithURL(srv.URL, jsonstoretest.StoreKey, jsonstore.WithLargeChunkSize(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.PutLarge("note", strings.Repeat("large value ", 10))
+	if err != nil {
+		t.Fatalf("PutLarge: %v", err)
+	}
+	c.Delete("note/_chunks/2")
+
+	var v string
+	err = c.GetLarge("note", &v)
+	if err == nil || !strings.Contains(err.Error(), "Missing chunk 2") {
+		t.Errorf("expected missing chunk error, got %v", err)
+	}
+	err = c.GetLarge("absent", &v)
+	if !errors.Is(err, jsonstore.ErrNoValue) {
+		t.Errorf("expected ErrNoValue for missing value, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// List lists the keys directly beneath prefix: the sorted field names of a stored object
+// or the indices of a stored array. Scalar values have no keys. An empty prefix lists
+// the keys at the root of the store. Returns ErrNoValue if nothing is stored at prefix.
+func (c *HttpClient) List(prefix string) ([]string, error) {
+	rawResponse, err := c.getTree(prefix)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.parseResponse(http.MethodGet, prefix, rawResponse)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.HasResult() {
+		return nil, ErrNoValue
+	}
+	var value interface{}
+	err = json.Unmarshal(resp.Result, &value)
+	if err != nil {
+		return nil, err
+	}
+	return childKeys(value), nil
+}
+
+// getTree gets the value at key like GetBytes, but also allows
+// an empty key to get the whole store.
+func (c *HttpClient) getTree(key string) ([]byte, error) {
+	if strings.Trim(key, "/") != "" {
+		return c.GetBytes(key)
+	}
+	req, err := c.newURLRequest(c.context(), http.MethodGet, c.createURL(""), nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.readBytes(req)
+}
+
+func childKeys(value interface{}) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	case []interface{}:
+		keys := make([]string, len(v))
+		for i := range v {
+			keys[i] = strconv.Itoa(i)
+		}
+		return keys
+	default:
+		return []string{}
+	}
+}
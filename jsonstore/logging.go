@@ -0,0 +1,43 @@
+package jsonstore
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes a request sent to jsonstore.
+// StatusCode is zero if no response was received and RequestID empty
+// unless WithRequestID is used.
+type RequestInfo struct {
+	Method     string
+	Key        string
+	URL        string
+	RequestID  string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// WithLogger makes the client call logf after every request it sends, including failed
+// requests and each attempt of retried requests.
+func WithLogger(logf func(ctx context.Context, info RequestInfo)) Option {
+	return func(c *HttpClient) {
+		c.logf = logf
+	}
+}
+
+func (c *HttpClient) logRequest(r *http.Request, status int, duration time.Duration, err error) {
+	if c.logf == nil {
+		return
+	}
+	c.logf(r.Context(), RequestInfo{
+		Method:     r.Method,
+		Key:        RequestKey(r),
+		URL:        r.URL.String(),
+		RequestID:  requestIDFromContext(r.Context()),
+		StatusCode: status,
+		Duration:   duration,
+		Err:        err,
+	})
+}
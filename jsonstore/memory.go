@@ -0,0 +1,199 @@
+package jsonstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MemoryClient in memory implementation of Client, useful in tests.
+// Nested keys such as todos/3/done address values inside stored objects and arrays.
+type MemoryClient struct {
+	mu   sync.RWMutex
+	root interface{}
+}
+
+// NewMemoryClient creates a new, empty MemoryClient.
+func NewMemoryClient() *MemoryClient {
+	return &MemoryClient{}
+}
+
+// Get gets a value from the store.
+func (m *MemoryClient) Get(key string, v interface{}) error {
+	rawResponse, err := m.GetBytes(key)
+	if err != nil {
+		return err
+	}
+	resp, err := newResponse(http.MethodGet, key, rawResponse)
+	if err != nil {
+		return err
+	}
+	if !resp.HasResult() {
+		return ErrNoValue
+	}
+	return resp.unmarshallResult(jsonCodec{}, v)
+}
+
+// GetBytes gets a value from the store wrapped in a jsonstore response.
+func (m *MemoryClient) GetBytes(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result, err := json.Marshal(lookupPath(m.root, splitKey(key)))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Response{
+		Result: result,
+		OK:     true,
+	})
+}
+
+// Post stores a value in the store.
+func (m *MemoryClient) Post(key string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return m.PostBytes(key, body)
+}
+
+// PostBytes stores raw JSON in the store.
+func (m *MemoryClient) PostBytes(key string, data []byte) error {
+	value, err := decodeValue(data)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.root = setPath(m.root, splitKey(key), value)
+	return nil
+}
+
+// Put updates the value of a given key in the store.
+func (m *MemoryClient) Put(key string, v interface{}) error {
+	return m.Post(key, v)
+}
+
+// PutBytes updates the value of a given key in the store.
+func (m *MemoryClient) PutBytes(key string, data []byte) error {
+	return m.PostBytes(key, data)
+}
+
+// Delete deletes the value of a key in the store.
+func (m *MemoryClient) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.root = deletePath(m.root, splitKey(key))
+	return nil
+}
+
+// Exists checks if a value is stored at a key.
+func (m *MemoryClient) Exists(key string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return lookupPath(m.root, splitKey(key)) != nil, nil
+}
+
+// Walk calls fn with the key and raw JSON of each leaf stored beneath prefix, like HttpClient.Walk.
+func (m *MemoryClient) Walk(ctx context.Context, prefix string, fn func(key string, value json.RawMessage) error) error {
+	tree, err := m.tree(prefix)
+	if err != nil {
+		return err
+	}
+	return walkValue(ctx, prefix, tree, true, fn)
+}
+
+// tree returns everything stored beneath prefix, returning ErrNoValue if nothing is.
+func (m *MemoryClient) tree(prefix string) (json.RawMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	node := lookupPath(m.root, splitKey(prefix))
+	if node == nil {
+		return nil, ErrNoValue
+	}
+	return json.Marshal(node)
+}
+
+func splitKey(key string) []string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(key, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+func lookupPath(node interface{}, segments []string) interface{} {
+	for _, segment := range segments {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[segment]
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil
+			}
+			node = n[i]
+		default:
+			return nil
+		}
+	}
+	return node
+}
+
+func setPath(node interface{}, segments []string, value interface{}) interface{} {
+	if len(segments) == 0 {
+		return value
+	}
+	head, rest := segments[0], segments[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		n[head] = setPath(n[head], rest, value)
+		return n
+	case []interface{}:
+		i, err := strconv.Atoi(head)
+		if err == nil && i >= 0 && i < len(n) {
+			n[i] = setPath(n[i], rest, value)
+			return n
+		}
+		if err == nil && i == len(n) {
+			return append(n, setPath(nil, rest, value))
+		}
+		// Like jsonstore, keys that do not fit the array turn it into an object
+		// keyed by the indexes of its elements.
+		obj := make(map[string]interface{}, len(n)+1)
+		for i, element := range n {
+			obj[strconv.Itoa(i)] = element
+		}
+		obj[head] = setPath(nil, rest, value)
+		return obj
+	}
+	return map[string]interface{}{
+		head: setPath(nil, rest, value),
+	}
+}
+
+func deletePath(node interface{}, segments []string) interface{} {
+	if len(segments) == 0 {
+		return nil
+	}
+	head, rest := segments[0], segments[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			delete(n, head)
+		} else if child, ok := n[head]; ok {
+			n[head] = deletePath(child, rest)
+		}
+	case []interface{}:
+		i, err := strconv.Atoi(head)
+		if err == nil && i >= 0 && i < len(n) {
+			n[i] = deletePath(n[i], rest)
+		}
+	}
+	return node
+}
@@ -0,0 +1,111 @@
+package jsonstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// memoryValue returns the JSON stored at key in m.
+func memoryValue(t *testing.T, m *MemoryClient, key string) string {
+	t.Helper()
+	var v json.RawMessage
+	err := m.Get(key, &v)
+	if err != nil {
+		return err.Error()
+	}
+	return string(v)
+}
+
+func TestMemoryClientArrayPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{"set element", "list/1", "B", `["a","B"]`},
+		{"append element", "list/2", "c", `["a","b","c"]`},
+		{"out of range index", "list/5", "x", `{"0":"a","1":"b","5":"x"}`},
+		{"negative index", "list/-1", "x", `{"-1":"x","0":"a","1":"b"}`},
+		{"non numeric key", "list/name", "x", `{"0":"a","1":"b","name":"x"}`},
+		{"nested in element", "list/0/title", "x", `[{"title":"x"},"b"]`},
+	}
+	for _, test := range tests {
+		m := NewMemoryClient()
+		err := m.Post("list", []string{"a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = m.Post(test.key, test.value)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := memoryValue(t, m, "list"); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestMemoryClientNestedArrays(t *testing.T) {
+	m := NewMemoryClient()
+	err := m.Post("todos", map[string]interface{}{
+		"lists": []interface{}{
+			map[string]interface{}{"items": []string{"a", "b"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := memoryValue(t, m, "todos/lists/0/items/1"); got != `"b"` {
+		t.Errorf("Expected \"b\", got %s", got)
+	}
+	if got := memoryValue(t, m, "todos/lists/1"); got != ErrNoValue.Error() {
+		t.Errorf("Expected no value past the end of the array, got %s", got)
+	}
+
+	err = m.Put("todos/lists/0/items/2", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Put("todos/lists/0/items/9", "z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"0":"a","1":"b","2":"c","9":"z"}`
+	if got := memoryValue(t, m, "todos/lists/0/items"); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	err = m.Delete("todos/lists/0/items/9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"0":"a","1":"b","2":"c"}`
+	if got := memoryValue(t, m, "todos/lists/0/items"); got != expected {
+		t.Errorf("Expected %s after delete, got %s", expected, got)
+	}
+}
+
+func TestMemoryClientWalk(t *testing.T) {
+	m := NewMemoryClient()
+	err := m.Post("todos", map[string]interface{}{"1": map[string]interface{}{"tags": []string{"a"}, "done": true}, "2": map[string]interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leaves []string
+	err = m.Walk(context.Background(), "todos", func(key string, value json.RawMessage) error {
+		leaves = append(leaves, key+"="+string(value))
+		return nil
+	})
+	expected := []string{"todos/1/done=true", "todos/1/tags/0=\"a\"", "todos/2={}"}
+	if err != nil || !reflect.DeepEqual(leaves, expected) {
+		t.Errorf("Expected leaves %v, got %v, %v", expected, leaves, err)
+	}
+	err = m.Walk(context.Background(), "missing", func(string, json.RawMessage) error { return nil })
+	if !errors.Is(err, ErrNoValue) {
+		t.Errorf("Expected ErrNoValue for a missing prefix, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package jsonstore
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseMeta describes the last response received by a client.
+//
+// RateLimit, RateLimitRemaining and RateLimitReset are parsed from the X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset headers. The limits are -1 and the reset time
+// is zero when the server does not send the header. X-RateLimit-Reset is read as a unix
+// timestamp in seconds, or as a number of seconds from now for values below one billion.
+type ResponseMeta struct {
+	StatusCode         int
+	RateLimit          int
+	RateLimitRemaining int
+	RateLimitReset     time.Time
+	Received           time.Time
+}
+
+// LastResponseMeta returns the metadata of the last response received by the client,
+// including failed responses and responses received by clients derived with With.
+// Returns the zero ResponseMeta if no response has been received yet.
+func (c *HttpClient) LastResponseMeta() ResponseMeta {
+	meta := c.lastMeta.Load()
+	if meta == nil {
+		return ResponseMeta{}
+	}
+	return *meta
+}
+
+func (c *HttpClient) recordMeta(resp *http.Response, now time.Time) {
+	c.lastMeta.Store(&ResponseMeta{
+		StatusCode:         resp.StatusCode,
+		RateLimit:          parseHeaderInt(resp.Header, "X-RateLimit-Limit"),
+		RateLimitRemaining: parseHeaderInt(resp.Header, "X-RateLimit-Remaining"),
+		RateLimitReset:     parseRateLimitReset(resp.Header.Get("X-RateLimit-Reset"), now),
+		Received:           now,
+	})
+}
+
+func parseHeaderInt(header http.Header, name string) int {
+	n, err := strconv.Atoi(header.Get(name))
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+func parseRateLimitReset(value string, now time.Time) time.Time {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return time.Time{}
+	}
+	if n < 1e9 {
+		return now.Add(time.Duration(n) * time.Second)
+	}
+	return time.Unix(n, 0)
+}
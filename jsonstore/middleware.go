@@ -0,0 +1,96 @@
+package jsonstore
+
+import (
+	"io"
+	"net/http"
+)
+
+// Middleware decorates the http.RoundTripper used to send requests.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// WithRoundTripper decorates the transport of the client with middleware,
+// the first middleware being the outermost. The transport of a client given
+// with WithHTTPClient is wrapped without modifying that client, otherwise
+// the default transport of the client is used as the base.
+func WithRoundTripper(mw ...Middleware) Option {
+	return func(c *HttpClient) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+func chainMiddleware(base http.RoundTripper, mw []Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(mw) - 1; i >= 0; i-- {
+		base = mw[i](base)
+	}
+	return base
+}
+
+type requestKeyContextKey struct{}
+
+// RequestKey returns the key that a request sent by the client refers to,
+// or an empty string for requests not made for a key, such as Ping.
+func RequestKey(r *http.Request) string {
+	key, _ := r.Context().Value(requestKeyContextKey{}).(string)
+	return key
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// MaxBodySize returns a Middleware that fails with ErrPayloadTooLarge for any request
+// whose body exceeds n bytes. Requests of known length are rejected before anything
+// is sent, streamed bodies are cut off as soon as they pass the limit.
+func MaxBodySize(n int64) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Body == nil || r.Body == http.NoBody {
+				return next.RoundTrip(r)
+			}
+			if r.ContentLength > n {
+				r.Body.Close()
+				return nil, ErrPayloadTooLarge
+			}
+			if r.ContentLength <= 0 {
+				limited := r.Clone(r.Context())
+				limited.Body = &maxBytesBody{body: r.Body, remaining: n, err: ErrPayloadTooLarge}
+				r = limited
+			}
+			return next.RoundTrip(r)
+		})
+	}
+}
+
+// maxBytesBody fails with err once more than remaining bytes are read from body.
+type maxBytesBody struct {
+	body      io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+	return n, err
+}
+
+func (b *maxBytesBody) Close() error {
+	return b.body.Close()
+}
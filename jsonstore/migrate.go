@@ -0,0 +1,94 @@
+package jsonstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// MigrateOption configures Migrate.
+type MigrateOption func(*migrateConfig)
+
+type migrateConfig struct {
+	transform func(key string, value json.RawMessage) (string, json.RawMessage, error)
+	dryRun    func(key string, value json.RawMessage)
+	hasDryRun bool
+}
+
+// MigrateTransform makes Migrate write the key and value returned by fn in place of each
+// key and value it reads. Returning an empty key skips the value, returning an error stops
+// the migration.
+func MigrateTransform(fn func(key string, value json.RawMessage) (string, json.RawMessage, error)) MigrateOption {
+	return func(mc *migrateConfig) {
+		mc.transform = fn
+	}
+}
+
+// MigrateDryRun makes Migrate call report, if not nil, with each key and value it would
+// write instead of writing it, so that the returned count is what would be copied.
+func MigrateDryRun(report func(key string, value json.RawMessage)) MigrateOption {
+	return func(mc *migrateConfig) {
+		mc.dryRun = report
+		mc.hasDryRun = true
+	}
+}
+
+// Migrate copies everything stored beneath prefix to the same keys in dst, for example from
+// a development store to a production one, and returns the number of values copied. An empty
+// prefix copies the whole store. The values are read with a single request and written with
+// a Post for each leaf found by Walk, except that arrays are written whole. Values already
+// stored in dst beneath prefix are kept unless overwritten. Returns ErrNoValue if nothing
+// is stored at prefix. The migration uses the context of the client, see WithContext.
+func (c *HttpClient) Migrate(dst Client, prefix string, opts ...MigrateOption) (int, error) {
+	ctx := c.context()
+	tree, err := c.tree(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	return migrate(ctx, prefix, tree, dst, opts)
+}
+
+// Migrate copies everything stored beneath prefix to dst, like HttpClient.Migrate.
+func (m *MemoryClient) Migrate(dst Client, prefix string, opts ...MigrateOption) (int, error) {
+	tree, err := m.tree(prefix)
+	if err != nil {
+		return 0, err
+	}
+	return migrate(context.Background(), prefix, tree, dst, opts)
+}
+
+// migrate writes each leaf of tree, which is stored at prefix, to dst.
+func migrate(ctx context.Context, prefix string, tree json.RawMessage, dst Client, opts []MigrateOption) (int, error) {
+	var config migrateConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	copied := 0
+	err := walkValue(ctx, prefix, tree, false, func(key string, value json.RawMessage) error {
+		dstKey := key
+		if config.transform != nil {
+			var err error
+			dstKey, value, err = config.transform(key, value)
+			if err != nil {
+				return fmt.Errorf("Could not transform '%s': %w", key, err)
+			}
+		}
+		if dstKey == "" {
+			return nil
+		}
+		if config.hasDryRun {
+			if config.dryRun != nil {
+				config.dryRun(dstKey, value)
+			}
+			copied++
+			return nil
+		}
+		err := dst.PostBytes(dstKey, value)
+		if err != nil {
+			return fmt.Errorf("Could not migrate '%s' to '%s': %w", key, dstKey, err)
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
@@ -0,0 +1,115 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newMigrateSource returns a MemoryClient holding todos, users and a version.
+func newMigrateSource(t *testing.T) *MemoryClient {
+	t.Helper()
+	src := NewMemoryClient()
+	err := src.PostBytes("", []byte(`{
+		"todos": {"1": {"title": "First", "tags": ["a", "b"]}, "2": {"title": "Second", "done": true}},
+		"users": {"ann": {"name": "Ann"}},
+		"version": 3
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func TestMigrateCopiesPrefix(t *testing.T) {
+	src := newMigrateSource(t)
+	dst := NewMemoryClient()
+	err := dst.Post("todos/3", map[string]string{"title": "Kept"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := src.Migrate(dst, "todos")
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if copied != 4 {
+		t.Errorf("Expected 4 values to be copied, got %d", copied)
+	}
+	expected := `{"1":{"tags":["a","b"],"title":"First"},"2":{"done":true,"title":"Second"},"3":{"title":"Kept"}}`
+	if v := memoryValue(t, dst, "todos"); v != expected {
+		t.Errorf("Expected todos %s, got %s", expected, v)
+	}
+	if exists, _ := dst.Exists("users"); exists {
+		t.Error("Expected values outside the prefix not to be copied")
+	}
+
+	copied, err = src.Migrate(NewMemoryClient(), "")
+	if err != nil || copied != 6 {
+		t.Errorf("Expected the whole store of 6 values to be copied, got %d, %v", copied, err)
+	}
+	_, err = src.Migrate(dst, "missing")
+	if !errors.Is(err, ErrNoValue) {
+		t.Errorf("Expected ErrNoValue for a missing prefix, got %v", err)
+	}
+}
+
+func TestMigrateTransform(t *testing.T) {
+	src := newMigrateSource(t)
+	dst := NewMemoryClient()
+
+	copied, err := src.Migrate(dst, "todos", MigrateTransform(func(key string, value json.RawMessage) (string, json.RawMessage, error) {
+		if strings.HasSuffix(key, "/tags") {
+			return "", nil, nil
+		}
+		if strings.HasSuffix(key, "/title") {
+			value = json.RawMessage(strings.ToUpper(string(value)))
+		}
+		return "archive/" + key, value, nil
+	}))
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if copied != 3 {
+		t.Errorf("Expected 3 values to be copied, got %d", copied)
+	}
+	expected := `{"todos":{"1":{"title":"FIRST"},"2":{"done":true,"title":"SECOND"}}}`
+	if v := memoryValue(t, dst, "archive"); v != expected {
+		t.Errorf("Expected archive %s, got %s", expected, v)
+	}
+
+	failure := errors.New("no users")
+	_, err = src.Migrate(dst, "users", MigrateTransform(func(key string, value json.RawMessage) (string, json.RawMessage, error) {
+		return "", nil, failure
+	}))
+	if !errors.Is(err, failure) || !strings.Contains(err.Error(), "users/ann/name") {
+		t.Errorf("Expected the transform error for users/ann/name, got %v", err)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	src := newMigrateSource(t)
+	dst := NewMemoryClient()
+
+	reported := make(map[string]string)
+	copied, err := src.Migrate(dst, "todos/1", MigrateDryRun(func(key string, value json.RawMessage) {
+		reported[key] = string(value)
+	}))
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	expected := map[string]string{"todos/1/tags": `["a","b"]`, "todos/1/title": `"First"`}
+	if copied != 2 || !reflect.DeepEqual(reported, expected) {
+		t.Errorf("Expected %v to be reported, got %d: %v", expected, copied, reported)
+	}
+	if exists, _ := dst.Exists("todos"); exists {
+		t.Error("Expected a dry run not to write to the destination")
+	}
+
+	copied, err = src.Migrate(dst, "", MigrateDryRun(nil))
+	if err != nil || copied != 6 {
+		t.Errorf("Expected a dry run without a report to count 6 values, got %d, %v", copied, err)
+	}
+}
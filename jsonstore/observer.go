@@ -0,0 +1,55 @@
+package jsonstore
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observer is notified of every request the client sends, including each attempt
+// of retried requests. key is empty for requests not made for a key, such as Ping.
+type Observer interface {
+	OnStart(method, key string)
+	OnComplete(method, key string, status int, duration time.Duration, err error)
+}
+
+// RequestIDObserver is an Observer that is also told the ID sent with each request,
+// which is empty unless WithRequestID is used. If the observer given to WithObserver
+// implements it, its methods are called instead of those of Observer.
+type RequestIDObserver interface {
+	Observer
+	OnStartRequest(method, key, requestID string)
+	OnCompleteRequest(method, key, requestID string, status int, duration time.Duration, err error)
+}
+
+// WithObserver makes the client notify obs of the requests it sends, a dependency
+// free alternative to the jsonstoreotel and jsonstoreprom packages. Panics in obs
+// are recovered and do not affect the request.
+func WithObserver(obs Observer) Option {
+	return func(c *HttpClient) {
+		c.observer = obs
+	}
+}
+
+func (c *HttpClient) observeStart(r *http.Request) {
+	if c.observer == nil {
+		return
+	}
+	defer func() { recover() }()
+	if obs, ok := c.observer.(RequestIDObserver); ok {
+		obs.OnStartRequest(r.Method, RequestKey(r), requestIDFromContext(r.Context()))
+		return
+	}
+	c.observer.OnStart(r.Method, RequestKey(r))
+}
+
+func (c *HttpClient) observeComplete(r *http.Request, status int, duration time.Duration, err error) {
+	if c.observer == nil {
+		return
+	}
+	defer func() { recover() }()
+	if obs, ok := c.observer.(RequestIDObserver); ok {
+		obs.OnCompleteRequest(r.Method, RequestKey(r), requestIDFromContext(r.Context()), status, duration, err)
+		return
+	}
+	c.observer.OnComplete(r.Method, RequestKey(r), status, duration, err)
+}
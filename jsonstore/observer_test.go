@@ -0,0 +1,136 @@
+package jsonstore
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type observedRequest struct {
+	method    string
+	key       string
+	requestID string
+	status    int
+	err       error
+}
+
+// recordingObserver records the requests it is notified of.
+type recordingObserver struct {
+	mu        sync.Mutex
+	started   []observedRequest
+	completed []observedRequest
+}
+
+func (o *recordingObserver) OnStart(method, key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started = append(o.started, observedRequest{method: method, key: key})
+}
+
+func (o *recordingObserver) OnComplete(method, key string, status int, duration time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.completed = append(o.completed, observedRequest{method: method, key: key, status: status, err: err})
+}
+
+// idObserver records the requests it is notified of along with their IDs.
+type idObserver struct {
+	recordingObserver
+}
+
+func (o *idObserver) OnStartRequest(method, key, requestID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started = append(o.started, observedRequest{method: method, key: key, requestID: requestID})
+}
+
+func (o *idObserver) OnCompleteRequest(method, key, requestID string, status int, duration time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.completed = append(o.completed, observedRequest{method: method, key: key, requestID: requestID, status: status, err: err})
+}
+
+func TestObserverIsNotified(t *testing.T) {
+	obs := &recordingObserver{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, `1`)
+	}, WithObserver(obs))
+
+	c.Get("counter", new(int))
+	c.Post("todos/1", "write tests")
+	if len(obs.started) != 2 || len(obs.completed) != 2 {
+		t.Fatalf("expected 2 started and completed requests, got %+v and %+v", obs.started, obs.completed)
+	}
+	expected := []observedRequest{
+		{method: http.MethodGet, key: "counter", status: http.StatusOK},
+		{method: http.MethodPost, key: "todos/1", status: http.StatusOK},
+	}
+	for i, req := range obs.completed {
+		if req != expected[i] {
+			t.Errorf("request %d: expected %+v, got %+v", i+1, expected[i], req)
+		}
+	}
+}
+
+func TestObserverReceivesRequestID(t *testing.T) {
+	var requests atomic.Int32
+	var sent []string
+	obs := &idObserver{}
+	var id atomic.Int32
+	gen := func() string {
+		return fmt.Sprintf("request-%d", id.Add(1))
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Header.Get(RequestIDHeader))
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}, WithObserver(obs), WithRequestID(gen), WithRetry(2, time.Second), WithClock(newFakeClock()))
+
+	err := c.Get("counter", new(int))
+	if err == nil || !strings.Contains(err.Error(), "request-1") {
+		t.Errorf("expected error to include the request ID, got %v", err)
+	}
+	if len(obs.started) != 2 || len(obs.completed) != 2 {
+		t.Fatalf("expected 2 attempts to be observed, got %+v and %+v", obs.started, obs.completed)
+	}
+	for i := 0; i < 2; i++ {
+		if obs.started[i].requestID != "request-1" || obs.completed[i].requestID != "request-1" {
+			t.Errorf("attempt %d: expected request ID request-1, got %+v and %+v", i+1, obs.started[i], obs.completed[i])
+		}
+		if sent[i] != "request-1" {
+			t.Errorf("attempt %d: expected header request-1, got %s", i+1, sent[i])
+		}
+	}
+	if obs.completed[1].status != http.StatusNotFound || obs.completed[1].err == nil {
+		t.Errorf("expected failed attempt to be observed, got %+v", obs.completed[1])
+	}
+}
+
+type panickingObserver struct{}
+
+func (panickingObserver) OnStart(method, key string) {
+	panic("start")
+}
+
+func (panickingObserver) OnComplete(method, key string, status int, duration time.Duration, err error) {
+	panic("complete")
+}
+
+func TestObserverPanicsAreRecovered(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, `1`)
+	}, WithObserver(panickingObserver{}))
+
+	var v int
+	err := c.Get("counter", &v)
+	if err != nil || v != 1 {
+		t.Fatalf("expected request to succeed, got %d, %v", v, err)
+	}
+}
@@ -0,0 +1,125 @@
+package jsonstore
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	defaultTimeout     = 5 * time.Second
+	defaultConcurrency = 8
+	defaultUserAgent   = "jsonstore-go-client/" + Version
+
+	defaultMaxResponseSize = 32 << 20
+
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 16
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+var defaultSuccessStatuses = []int{http.StatusOK, http.StatusCreated, http.StatusNoContent}
+
+// Option configures an HttpClient.
+type Option func(*HttpClient)
+
+// WithHTTPClient makes the client send requests with hc instead of the default
+// http.Client. The caller becomes responsible for configuring its timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *HttpClient) {
+		c.httpClient = hc
+	}
+}
+
+// WithPathPrefix sets the path the jsonstore API is mounted under, such as /api/v1,
+// which is added between the path of the base url and the store key:
+// <base url>/api/v1/<store key>/<key>.
+func WithPathPrefix(p string) Option {
+	return func(c *HttpClient) {
+		c.pathPrefix = p
+	}
+}
+
+// WithTimeout sets the timeout of the http.Client created by the client,
+// zero means no timeout. Defaults to 5 seconds and has no effect together with WithHTTPClient.
+func WithTimeout(d time.Duration) Option {
+	return func(c *HttpClient) {
+		c.timeout = d
+	}
+}
+
+// WithDefaultDeadline limits each request, including retries and reading the response, to d,
+// like WithRequestTimeout does for the requests of a client returned by With, which takes precedence.
+// Unlike WithTimeout it also applies with WithHTTPClient. Requests over the deadline fail with
+// an error matching context.DeadlineExceeded.
+func WithDefaultDeadline(d time.Duration) Option {
+	return func(c *HttpClient) {
+		c.request.timeout = d
+	}
+}
+
+// WithConcurrency sets the maximum number of requests sent at once
+// by batch operations such as GetMany. Defaults to 8. Batch operations send
+// fewer requests at once while requests fail with a 429 or 5xx status and
+// recover gradually as they succeed again, see LastBatchStats.
+func WithConcurrency(n int) Option {
+	return func(c *HttpClient) {
+		c.concurrency = n
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+// Defaults to jsonstore-go-client/<Version>.
+func WithUserAgent(ua string) Option {
+	return func(c *HttpClient) {
+		c.userAgent = ua
+	}
+}
+
+// WithHeaders sets headers sent with every request. They replace the Accept, Content-type
+// and User-Agent headers set by the client and are replaced by headers set with WithRequestHeader.
+func WithHeaders(h http.Header) Option {
+	return func(c *HttpClient) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		for name, values := range h {
+			c.headers[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+}
+
+// WithMaxResponseSize limits the size of response bodies read by the client to n bytes,
+// reading past the limit fails with ErrResponseTooLarge. Compressed responses are limited
+// by their decompressed size. Defaults to 32 MiB, n <= 0 removes the limit. The default
+// does not apply to GetTo, which streams the response instead of holding it in memory.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *HttpClient) {
+		c.maxResponseSize = n
+		c.hasMaxSize = true
+	}
+}
+
+// WithSuccessStatuses sets the response status codes treated as successful
+// by both reads and writes. Defaults to 200, 201 and 204.
+func WithSuccessStatuses(codes ...int) Option {
+	return func(c *HttpClient) {
+		c.successStatuses = statusSet(codes)
+	}
+}
+
+// WithValidator makes Post, Put and the methods built on them, such as PutIfMatch and Update,
+// call fn with the value before marshalling it and fail with the error fn returns.
+// Raw bytes written with PostBytes, PutBytes or the Reader methods are not validated.
+func WithValidator(fn func(v interface{}) error) Option {
+	return func(c *HttpClient) {
+		c.validator = fn
+	}
+}
+
+func statusSet(codes []int) map[int]bool {
+	set := make(map[int]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return set
+}
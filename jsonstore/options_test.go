@@ -0,0 +1,133 @@
+package jsonstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sizedHandler answers every request with a jsonstore response of n bytes.
+func sizedHandler(n int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, `"`+strings.Repeat("x", n-len(`{"ok":true,"result":""}`))+`"`)
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	c := newTestClient(t, sizedHandler(2048), WithMaxResponseSize(1024))
+
+	_, err := c.GetBytes("large")
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge from GetBytes, got %v", err)
+	}
+	var v string
+	err = c.Get("large", &v)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge from Get, got %v", err)
+	}
+	var buf bytes.Buffer
+	_, err = c.GetTo("large", &buf)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected explicit limit to apply to GetTo, got %v", err)
+	}
+
+	c = newTestClient(t, sizedHandler(1024), WithMaxResponseSize(1024))
+	data, err := c.GetBytes("large")
+	if err != nil || len(data) != 1024 {
+		t.Fatalf("expected response at the limit to be read, got %d bytes, %v", len(data), err)
+	}
+}
+
+func TestDefaultMaxResponseSize(t *testing.T) {
+	size := defaultMaxResponseSize + 1024
+	c := newTestClient(t, sizedHandler(size))
+
+	_, err := c.GetBytes("large")
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected default limit to apply to GetBytes, got %v", err)
+	}
+	n, err := c.GetTo("large", ioutil.Discard)
+	if err != nil || n != int64(size) {
+		t.Fatalf("expected GetTo to stream the whole response, got %d bytes, %v", n, err)
+	}
+
+	c = newTestClient(t, sizedHandler(size), WithMaxResponseSize(0))
+	data, err := c.GetBytes("large")
+	if err != nil || len(data) != size {
+		t.Fatalf("expected no limit, got %d bytes, %v", len(data), err)
+	}
+}
+
+// slowHandler answers requests after delay, or when the client goes away.
+func slowHandler(delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		writeResult(w, `1`)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	c := newTestClient(t, slowHandler(time.Second), WithTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	var v int
+	err := c.Get("slow", &v)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected request to time out after 20ms, took %s", elapsed)
+	}
+}
+
+func TestZeroTimeoutMeansNoTimeout(t *testing.T) {
+	c := newTestClient(t, slowHandler(50*time.Millisecond), WithTimeout(0))
+	if c.httpClient.Timeout != 0 {
+		t.Errorf("Expected no http.Client timeout, got %s", c.httpClient.Timeout)
+	}
+
+	var v int
+	err := c.Get("slow", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+}
+
+func TestDefaultDeadline(t *testing.T) {
+	tests := []struct {
+		name string
+		h    http.HandlerFunc
+		opts []Option
+	}{
+		{"slow response", slowHandler(time.Second), nil},
+		{"with http client", slowHandler(time.Second), []Option{WithHTTPClient(&http.Client{})}},
+		{"during retry sleep", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, []Option{WithRetry(3, time.Second)}},
+	}
+	for _, test := range tests {
+		c := newTestClient(t, test.h, append(test.opts, WithDefaultDeadline(20*time.Millisecond))...)
+
+		start := time.Now()
+		var v int
+		err := c.Get("slow", &v)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected context.DeadlineExceeded, got %v", test.name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("%s: expected request to fail after 20ms, took %s", test.name, elapsed)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Patch merges the fields of v into the value stored at key.
+func (c *HttpClient) Patch(key string, v interface{}) error {
+	body, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.PatchBytes(key, body)
+}
+
+// PatchBytes merges a JSON object into the value stored at key following JSON merge patch
+// semantics (RFC 7386): objects are merged recursively and null fields are removed.
+// If the backend does not support PATCH the merge is done by reading the current value
+// and writing back the merged result, which is not atomic.
+func (c *HttpClient) PatchBytes(key string, data []byte) error {
+	req, err := c.newBytesRequest(http.MethodPatch, key, data)
+	if err != nil {
+		return err
+	}
+	_, err = c.performRequest(key, req)
+	if !isUnsupportedMethod(err) {
+		return err
+	}
+	return c.patchByRewrite(key, data)
+}
+
+func (c *HttpClient) patchByRewrite(key string, data []byte) error {
+	patch, err := decodeValue(data)
+	if err != nil {
+		return err
+	}
+	var rawCurrent json.RawMessage
+	err = c.Get(key, &rawCurrent)
+	exists := err == nil
+	if err != nil && !errors.Is(err, ErrNoValue) {
+		return err
+	}
+	var current interface{}
+	if exists {
+		current, err = decodeValue(rawCurrent)
+		if err != nil {
+			return err
+		}
+	}
+	return c.set(key, mergePatch(current, patch), exists)
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{}, len(patchObj))
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergePatch(targetObj[k], v)
+	}
+	return targetObj
+}
+
+func isUnsupportedMethod(err error) bool {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	switch httpErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,134 @@
+package jsonstore_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore"
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore/jsonstoretest"
+)
+
+func TestPatchMergesNestedKeys(t *testing.T) {
+	// jsonstoretest does not support PATCH, so this goes through the read-modify-write fallback.
+	srv, client := jsonstoretest.NewServer()
+	defer srv.Close()
+
+	err := client.Put("users/1", map[string]interface{}{
+		"name": "Ada",
+		"address": map[string]interface{}{
+			"city":    "London",
+			"street":  "Baker Street",
+			"country": "UK",
+		},
+		"tags": []string{"admin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.PatchBytes("users/1", []byte(`{
+		"address": {"city": "Cambridge", "street": null, "zip": "CB1"},
+		"tags": ["user"],
+		"age": 36
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var user map[string]interface{}
+	err = client.Get("users/1", &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name": "Ada",
+		"address": map[string]interface{}{
+			"city":    "Cambridge",
+			"country": "UK",
+			"zip":     "CB1",
+		},
+		"tags": []interface{}{"user"},
+		"age":  float64(36),
+	}
+	if !reflect.DeepEqual(user, expected) {
+		t.Errorf("Expected %v, got %v", expected, user)
+	}
+}
+
+func TestPatchNestedPath(t *testing.T) {
+	srv, client := jsonstoretest.NewServer()
+	defer srv.Close()
+
+	err := client.Put("todos", map[string]interface{}{
+		"1": map[string]interface{}{"title": "Write tests", "done": false},
+		"2": map[string]interface{}{"title": "Ship it", "done": false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Patch("todos/1", map[string]interface{}{"done": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var todos map[string]todo
+	err = client.Get("todos", &todos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]todo{
+		"1": {Title: "Write tests", Done: true},
+		"2": {Title: "Ship it", Done: false},
+	}
+	if !reflect.DeepEqual(todos, expected) {
+		t.Errorf("Expected %v, got %v", expected, todos)
+	}
+}
+
+func TestPatchCreatesMissingValue(t *testing.T) {
+	srv, client := jsonstoretest.NewServer()
+	defer srv.Close()
+
+	err := client.Patch("settings", map[string]interface{}{"theme": "dark", "unset": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings map[string]interface{}
+	err = client.Get("settings", &settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"theme": "dark"}
+	if !reflect.DeepEqual(settings, expected) {
+		t.Errorf("Expected %v, got %v", expected, settings)
+	}
+}
+
+func TestPatchUsesNativePatch(t *testing.T) {
+	var methods []string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		body, _ = ioutil.ReadAll(r.Body)
+		json.NewEncoder(w).Encode(jsonstore.Response{OK: true, Result: json.RawMessage(`null`)})
+	}))
+	defer srv.Close()
+	client, err := jsonstore.NewClientWithURL(srv.URL, jsonstoretest.StoreKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.PatchBytes("users/1", []byte(`{"age":36}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(methods, []string{http.MethodPatch}) {
+		t.Errorf("Expected a single PATCH request, got %v", methods)
+	}
+	if string(body) != `{"age":36}` {
+		t.Errorf("Expected the patch as body, got %s", body)
+	}
+}
@@ -0,0 +1,22 @@
+package jsonstore
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit limits the client to r requests per second with bursts of up to burst requests.
+// Requests over the limit wait for their turn, giving up if their context is done.
+func WithRateLimit(r rate.Limit, burst int) Option {
+	return func(c *HttpClient) {
+		c.limiter = rate.NewLimiter(r, burst)
+	}
+}
+
+func (c *HttpClient) waitForRateLimit(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.Wait(ctx)
+}
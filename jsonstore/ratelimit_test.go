@@ -0,0 +1,86 @@
+package jsonstore
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitSpacesRequests(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	var mu sync.Mutex
+	var received []time.Time
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, time.Now())
+		mu.Unlock()
+		writeResult(w, `1`)
+	}, WithRateLimit(rate.Every(interval), 1))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var v int
+			if err := c.Get("limited", &v); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(received) != 5 {
+		t.Fatalf("Expected 5 requests, got %d", len(received))
+	}
+	if total := received[4].Sub(received[0]); total < 4*interval*9/10 {
+		t.Errorf("Expected 5 requests to take at least %s, took %s", 4*interval, total)
+	}
+	for i := 1; i < len(received); i++ {
+		if gap := received[i].Sub(received[i-1]); gap < interval/2 {
+			t.Errorf("Request %d followed the previous one after %s, expected about %s", i, gap, interval)
+		}
+	}
+}
+
+func TestRateLimitAllowsBursts(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, `1`)
+	}, WithRateLimit(rate.Every(time.Hour), 3))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		var v int
+		if err := c.Get("limited", &v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected burst to pass without waiting, took %s", elapsed)
+	}
+}
+
+func TestRateLimitRespectsContext(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, `1`)
+	}, WithRateLimit(rate.Every(time.Hour), 1))
+
+	var v int
+	if err := c.Get("limited", &v); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.WithContext(ctx).Get("limited", &v)
+	if err == nil {
+		t.Fatal("Expected request over the rate limit to fail once its context is done")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected request to give up with its context, waited %s", elapsed)
+	}
+}
@@ -0,0 +1,113 @@
+package jsonstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// RequestOption adjusts the requests made through a client returned by HttpClient.With.
+type RequestOption func(*requestConfig)
+
+type requestConfig struct {
+	timeout time.Duration
+	headers http.Header
+	noRetry bool
+
+	contentType    string
+	hasContentType bool
+}
+
+// WithRequestTimeout limits each request, including retries and reading the response, to d.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(rc *requestConfig) {
+		rc.timeout = d
+	}
+}
+
+// WithRequestHeader sets a header on each request, replacing any value set by the client.
+func WithRequestHeader(name, value string) RequestOption {
+	return func(rc *requestConfig) {
+		if rc.headers == nil {
+			rc.headers = make(http.Header)
+		}
+		rc.headers.Set(name, value)
+	}
+}
+
+// WithContentType sets the Content-Type of the requests, which defaults to application/json,
+// for example to upload other data with PostReader. An empty ct omits the header.
+// Responses are still requested as application/json.
+func WithContentType(ct string) RequestOption {
+	return func(rc *requestConfig) {
+		rc.contentType = ct
+		rc.hasContentType = true
+	}
+}
+
+// WithoutRetry disables retries set up with WithRetry.
+func WithoutRetry() RequestOption {
+	return func(rc *requestConfig) {
+		rc.noRetry = true
+	}
+}
+
+// With returns a client which applies opts to its requests, on top of the options
+// already applied by c. The returned client shares its connections, cache and
+// limits with c, which is not affected, so it is cheap to create for a single call:
+//
+//	client.With(WithRequestHeader("X-Trace", id)).Get(key, &v)
+func (c *HttpClient) With(opts ...RequestOption) *HttpClient {
+	clone := *c
+	clone.closed = &closeState{parent: c.closed}
+	clone.request.headers = c.request.headers.Clone()
+	for _, opt := range opts {
+		opt(&clone.request)
+	}
+	return &clone
+}
+
+// WithContext returns a client whose requests are made with ctx, so that code written
+// against Client can be cancelled without changing its signatures. Every request made
+// through the returned client fails once ctx is done, so it should live no longer than
+// the operation ctx belongs to. Like the clients returned by With it shares its
+// connections, cache and limits with c.
+func (c *HttpClient) WithContext(ctx context.Context) Client {
+	clone := c.With()
+	clone.ctx = ctx
+	return clone
+}
+
+// ForStore returns a client for the store with storeKey on the same server as c.
+// Like the clients returned by With it shares its connections, cache and limits with c,
+// so a single client can serve many stores without creating a transport for each.
+func (c *HttpClient) ForStore(storeKey string) Client {
+	return c.WithStoreKey(storeKey)
+}
+
+// WithStoreKey returns a client using storeKey instead of the store key of c, for example
+// after the store key has been rotated. c is not affected and keeps using its store key.
+// Like the clients returned by With the new client shares its connections, cache and limits with c.
+func (c *HttpClient) WithStoreKey(storeKey string) *HttpClient {
+	clone := c.With()
+	u := *c.baseURL
+	u.RawPath = ""
+	u.Path = path.Join("/", strings.TrimSuffix(u.Path, c.storeKey), storeKey)
+	clone.baseURL = &u
+	clone.storeKey = strings.Trim(storeKey, "/")
+	return clone
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
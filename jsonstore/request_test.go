@@ -0,0 +1,223 @@
+package jsonstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestForStoreViewsConcurrently(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, h.ServeHTTP)
+	views := map[string]Client{
+		"first":  c.ForStore("first"),
+		"second": c.ForStore("second"),
+	}
+
+	var wg sync.WaitGroup
+	for storeKey, view := range views {
+		wg.Add(1)
+		go func(storeKey string, view Client) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				key := fmt.Sprintf("todos/%d", i)
+				err := view.Put(key, storeKey)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				var v string
+				err = view.Get(key, &v)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if v != storeKey {
+					t.Errorf("%s: expected %s at %s, got %s", storeKey, storeKey, key, v)
+				}
+			}
+		}(storeKey, view)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.values) != 40 {
+		t.Errorf("Expected 40 values, got %d", len(h.values))
+	}
+	for path, value := range h.values {
+		storeKey := strings.Split(path, "/")[1]
+		if value != `"`+storeKey+`"` {
+			t.Errorf("Expected %s to hold \"%s\", got %s", path, storeKey, value)
+		}
+	}
+	if u := c.createURL("todos"); !strings.HasSuffix(u, "/store/todos") {
+		t.Errorf("Expected original client to keep its store, got %s", u)
+	}
+}
+
+func TestHeadersPrecedence(t *testing.T) {
+	rr := &requestRecorder{}
+	c := newTestClient(t, rr.ServeHTTP, WithUserAgent("agent/1"), WithHeaders(http.Header{
+		"x-gateway-token": {"secret"},
+		"X-Correlation":   {"client"},
+		"Accept":          {"application/vnd.jsonstore+json"},
+	}))
+
+	methods := map[string]func(c *HttpClient) error{
+		http.MethodGet:    func(c *HttpClient) error { _, err := c.GetBytes("a"); return err },
+		http.MethodPost:   func(c *HttpClient) error { return c.Post("a", 1) },
+		http.MethodPut:    func(c *HttpClient) error { return c.Put("a", 1) },
+		http.MethodDelete: func(c *HttpClient) error { return c.Delete("a") },
+	}
+	for method, send := range methods {
+		err := send(c.With(WithRequestHeader("X-Correlation", "request")))
+		if err != nil {
+			t.Errorf("%s: %v", method, err)
+			continue
+		}
+		h := rr.last().Header
+		expected := map[string]string{
+			"X-Gateway-Token": "secret",
+			"X-Correlation":   "request",
+			"Accept":          "application/vnd.jsonstore+json",
+			"Content-Type":    "application/json",
+			"User-Agent":      "agent/1",
+		}
+		for name, value := range expected {
+			if got := h.Get(name); got != value {
+				t.Errorf("%s: expected %s: %s, got %q", method, name, value, got)
+			}
+		}
+		if n := len(h.Values("X-Correlation")); n != 1 {
+			t.Errorf("%s: expected a single X-Correlation header, got %d", method, n)
+		}
+	}
+
+	err := c.Delete("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rr.last().Header.Get("X-Correlation"); got != "client" {
+		t.Errorf("Expected client default X-Correlation without request header, got %q", got)
+	}
+}
+
+func TestContentTypeOverride(t *testing.T) {
+	rr := &requestRecorder{}
+	c := newTestClient(t, rr.ServeHTTP)
+
+	err := c.With(WithContentType("text/plain")).PostReader("notes/1", strings.NewReader("plain text"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := rr.last().Header
+	if ct := h.Get("Content-Type"); ct != "text/plain" {
+		t.Errorf("Expected Content-Type text/plain, got %q", ct)
+	}
+	if accept := h.Get("Accept"); accept != "application/json" {
+		t.Errorf("Expected Accept application/json, got %q", accept)
+	}
+
+	err = c.With(WithContentType("")).PutBytes("notes/1", []byte(`"no type"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rr.last().Header["Content-Type"]; ok {
+		t.Errorf("Expected no Content-Type, got %q", rr.last().Header.Get("Content-Type"))
+	}
+
+	err = c.PutBytes("notes/1", []byte(`"json"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := rr.last().Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected default Content-Type application/json, got %q", ct)
+	}
+}
+
+func TestWithStoreKeyConcurrently(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, h.ServeHTTP)
+	original := c.createURL("todos")
+
+	var wg sync.WaitGroup
+	for _, storeKey := range []string{"rotated-1", "rotated-2", "rotated-3"} {
+		wg.Add(1)
+		go func(storeKey string) {
+			defer wg.Done()
+			rotated := c.WithStoreKey(storeKey)
+			for i := 0; i < 10; i++ {
+				err := rotated.Put(fmt.Sprintf("todos/%d", i), storeKey)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			var v string
+			err := rotated.Get("todos/0", &v)
+			if err != nil || v != storeKey {
+				t.Errorf("%s: expected to read back %s, got %q, %v", storeKey, storeKey, v, err)
+			}
+		}(storeKey)
+	}
+	err := c.Put("todos/0", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	if u := c.createURL("todos"); u != original || c.storeKey != "store" {
+		t.Errorf("Expected original client to keep %s, got %s", original, u)
+	}
+	var v string
+	err = c.Get("todos/0", &v)
+	if err != nil || v != "store" {
+		t.Errorf("Expected original store to be unaffected, got %q, %v", v, err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.values) != 31 {
+		t.Errorf("Expected 31 values, got %d", len(h.values))
+	}
+}
+
+func TestClosingDerivedClientLeavesParentOpen(t *testing.T) {
+	rr := &requestRecorder{}
+	c := newTestClient(t, rr.ServeHTTP)
+	derived := map[string]*HttpClient{
+		"With":         c.With(),
+		"WithStoreKey": c.WithStoreKey("other"),
+		"ForStore":     c.ForStore("other").(*HttpClient),
+		"WithContext":  c.WithContext(context.Background()).(*HttpClient),
+	}
+	for name, d := range derived {
+		grandchild := d.With()
+		err := d.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Post("todos/1", 1); !errors.Is(err, ErrClientClosed) {
+			t.Errorf("%s: expected closed client to return ErrClientClosed, got %v", name, err)
+		}
+		if err := grandchild.Post("todos/1", 1); !errors.Is(err, ErrClientClosed) {
+			t.Errorf("%s: expected client derived from a closed client to be closed, got %v", name, err)
+		}
+		if err := c.Post("todos/1", 1); err != nil {
+			t.Errorf("%s: expected parent to keep working, got %v", name, err)
+		}
+	}
+
+	sibling := c.With()
+	err := c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sibling.Post("todos/1", 1); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Expected clients derived from a closed client to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package jsonstore
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// RequestIDHeader is the header carrying the ID of requests set up with WithRequestID.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDContextKey struct{}
+
+// WithRequestID sends a unique ID generated by gen in the X-Request-ID header of every
+// request, so that requests can be correlated with the logs of the server. A nil gen
+// generates random UUIDs. Retries of a request keep its ID. The ID is included in
+// HTTPErrors, in the RequestInfo passed to the logger set with WithLogger and passed
+// to observers implementing RequestIDObserver.
+func WithRequestID(gen func() string) Option {
+	return func(c *HttpClient) {
+		if gen == nil {
+			gen = newUUID
+		}
+		c.requestID = gen
+	}
+}
+
+// requestIDFromContext returns the ID of the request with ctx, empty if it has none.
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return ""
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,105 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type benchTodo struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+// largeResponse returns a jsonstore response holding n todos.
+func largeResponse(b *testing.B, n int) []byte {
+	todos := make(map[string]benchTodo, n)
+	for i := 0; i < n; i++ {
+		todos[fmt.Sprint(i)] = benchTodo{ID: int64(i), Title: fmt.Sprintf("Todo number %d", i)}
+	}
+	result, err := json.Marshal(todos)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, err := json.Marshal(Response{Result: result, OK: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+// decodeByRemarshal decodes a response the way unmarshallResult used to, decoding the
+// result into an interface{} and marshalling it again before decoding it into v.
+func decodeByRemarshal(data []byte, v interface{}) error {
+	var resp struct {
+		Result interface{} `json:"result"`
+		OK     bool        `json:"ok"`
+	}
+	err := json.Unmarshal(data, &resp)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(resp.Result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(result, v)
+}
+
+func BenchmarkDecodeResultRemarshal(b *testing.B) {
+	data := largeResponse(b, 10000)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var todos map[string]benchTodo
+		if err := decodeByRemarshal(data, &todos); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeResultRawMessage(b *testing.B) {
+	data := largeResponse(b, 10000)
+	c := NewClient("store")
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var todos map[string]benchTodo
+		if err := c.decodeResult("todos", data, &todos); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLargeIntegersKeepPrecision(t *testing.T) {
+	h := newStoreHandler()
+	c := newTestClient(t, h.ServeHTTP)
+
+	const id int64 = 9007199254740993 // 2^53 + 1, not representable as float64
+	err := c.Put("nextId", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got int64
+	err = c.Get("nextId", &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != id {
+		t.Errorf("Expected %d, got %d", id, got)
+	}
+
+	err = c.Put("todos/1", benchTodo{ID: id, Title: "Big"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var todo benchTodo
+	err = c.Get("todos/1", &todo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.ID != id {
+		t.Errorf("Expected nested id %d, got %d", id, todo.ID)
+	}
+}
@@ -0,0 +1,207 @@
+package jsonstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const maxRetryDelay = 30 * time.Second
+
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = 100 * time.Millisecond
+)
+
+type retryConfig struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	policy      RetryPolicy
+}
+
+// RetryPolicy decides whether to retry a request after its attempt-th attempt failed
+// and how long to wait before the next one. resp is the failed response, nil if none
+// was received, with a body holding at most the first 4 KiB of the response body.
+// err is the error of the attempt, an HTTPError for failed responses.
+type RetryPolicy func(attempt int, resp *http.Response, err error) (retry bool, delay time.Duration)
+
+// backoff returns the delay before the retry following attempt, doubling
+// baseDelay for every attempt and picking a random delay in the upper half.
+func (r retryConfig) backoff(attempt int) time.Duration {
+	delay := r.baseDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// WithRetry makes the client retry idempotent requests (GET, PUT and DELETE), and POST
+// requests made with WithIdempotency, up to maxAttempts times in total when they fail with
+// a network error, 429 or 5xx status. The delay between attempts starts at baseDelay and
+// doubles with every attempt, unless the response has a Retry-After header in which case
+// that delay is used. Responses asking for a delay over 30 seconds are not retried, their
+// HTTPError holds the delay in RetryAfter.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(c *HttpClient) {
+		c.retry = retryConfig{
+			maxAttempts: maxAttempts,
+			baseDelay:   baseDelay,
+		}
+	}
+}
+
+// WithRetryPolicy makes the client retry requests as decided by policy, replacing WithRetry.
+// policy is consulted for requests of every method, including POST, which may store a value
+// twice if the first attempt reached the server unless WithIdempotency is used. Retried
+// requests must have a replayable body, see PostReader. DefaultRetryPolicy can be wrapped
+// to retry additional failures.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *HttpClient) {
+		c.retry = retryConfig{policy: policy}
+	}
+}
+
+// DefaultRetryPolicy retries requests of any method failing with a network error, 429 or
+// 5xx status up to 3 attempts in total, waiting as asked for by a Retry-After header or
+// otherwise with a backoff starting at 100ms. Like WithRetry it does not retry responses
+// asking for a delay over 30 seconds.
+func DefaultRetryPolicy(attempt int, resp *http.Response, err error) (bool, time.Duration) {
+	if attempt >= defaultRetryAttempts || !isRetryable(err) {
+		return false, 0
+	}
+	return retryDelay(err, retryConfig{baseDelay: defaultRetryBaseDelay}.backoff(attempt))
+}
+
+// shouldRetry reports whether r should be sent again after its attempt-th attempt failed
+// with err, and the delay before doing so.
+func (c *HttpClient) shouldRetry(r *http.Request, attempt int, err error) (bool, time.Duration) {
+	if c.request.noRetry {
+		return false, 0
+	}
+	if c.retry.policy != nil {
+		return c.retry.policy(attempt, failedResponse(err), err)
+	}
+	if !isIdempotent(r.Method) && !hasIdempotencyKey(r) {
+		return false, 0
+	}
+	if attempt >= c.retry.maxAttempts || !isRetryable(err) {
+		return false, 0
+	}
+	return retryDelay(err, c.retry.backoff(attempt))
+}
+
+// failedResponse returns the response carried by err, if it is an HTTPError.
+func failedResponse(err error) *http.Response {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.response == nil {
+		return nil
+	}
+	resp := *httpErr.response
+	resp.Body = ioutil.NopCloser(bytes.NewReader(httpErr.Body))
+	return &resp
+}
+
+// retryDelay returns the delay asked for by the server if err carries one, otherwise backoff.
+// Reports false if the server asked for a delay over maxRetryDelay, which is left to the caller.
+func retryDelay(err error, backoff time.Duration) (bool, time.Duration) {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.RetryAfter <= 0 {
+		return true, backoff
+	}
+	if httpErr.RetryAfter > maxRetryDelay {
+		return false, 0
+	}
+	return true, httpErr.RetryAfter
+}
+
+// parseRetryAfter parses a Retry-After header given either as seconds or as an HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(header)
+	if err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	date, err := http.ParseTime(header)
+	if err != nil || !date.After(now) {
+		return 0
+	}
+	return date.Sub(now)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryable reports whether a failed attempt may succeed when sent again, that is
+// whether it failed with a network error or a 429 or 5xx status. Errors raised by the
+// client itself, such as ErrClientClosed or ErrPayloadTooLarge, are not retryable.
+func isRetryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+	return isNetworkError(err)
+}
+
+// isNetworkError reports whether err was caused by the network, such as a refused
+// connection, a timeout or a connection closed before the response was received.
+func isNetworkError(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// url.Error implements net.Error itself, so look at the error it wraps.
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// rewindBody resets the body of r so it can be sent again,
+// reporting false if the body cannot be replayed.
+func rewindBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true
+	}
+	if r.GetBody == nil {
+		return false
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return false
+	}
+	r.Body = body
+	return true
+}
+
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	select {
+	case <-clock.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,376 @@
+package jsonstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetrySucceedsAfterFailures(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		for failures := 0; failures < 3; failures++ {
+			clock := newFakeClock()
+			h := failingHandler(failures, http.StatusServiceUnavailable)
+			c := newTestClient(t, h.ServeHTTP, WithRetry(3, 100*time.Millisecond), WithClock(clock))
+
+			var err error
+			switch method {
+			case http.MethodGet:
+				var v int
+				err = c.Get("counter", &v)
+			case http.MethodPut:
+				err = c.Put("counter", 1)
+			case http.MethodDelete:
+				err = c.Delete("counter")
+			}
+			if err != nil {
+				t.Fatalf("%s after %d failures: unexpected error: %v", method, failures, err)
+			}
+			if n := int(h.requests.Load()); n != failures+1 {
+				t.Errorf("%s after %d failures: expected %d requests, got %d", method, failures, failures+1, n)
+			}
+			if n := len(clock.Waits()); n != failures {
+				t.Errorf("%s after %d failures: expected %d waits, got %d", method, failures, failures, n)
+			}
+		}
+	}
+}
+
+func TestRetryBacksOffExponentially(t *testing.T) {
+	clock := newFakeClock()
+	h := failingHandler(4, http.StatusBadGateway)
+	c := newTestClient(t, h.ServeHTTP, WithRetry(5, 100*time.Millisecond), WithClock(clock))
+
+	var v int
+	err := c.Get("counter", &v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waits := clock.Waits()
+	if len(waits) != 4 {
+		t.Fatalf("expected 4 waits, got %v", waits)
+	}
+	delay := 100 * time.Millisecond
+	for i, wait := range waits {
+		if wait < delay/2 || wait > delay {
+			t.Errorf("wait %d: expected delay in [%s, %s], got %s", i, delay/2, delay, wait)
+		}
+		delay *= 2
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	h := failingHandler(10, http.StatusInternalServerError)
+	c := newTestClient(t, h.ServeHTTP, WithRetry(3, time.Second), WithClock(newFakeClock()))
+
+	var v int
+	err := c.Get("counter", &v)
+	if code, ok := StatusCode(err); !ok || code != http.StatusInternalServerError {
+		t.Fatalf("expected HTTPError with status 500, got %v", err)
+	}
+	if n := h.requests.Load(); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestRetrySkipsClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict} {
+		clock := newFakeClock()
+		h := failingHandler(10, status)
+		c := newTestClient(t, h.ServeHTTP, WithRetry(3, time.Second), WithClock(clock))
+
+		var v int
+		err := c.Get("counter", &v)
+		if code, _ := StatusCode(err); code != status {
+			t.Fatalf("expected HTTPError with status %d, got %v", status, err)
+		}
+		if n := h.requests.Load(); n != 1 {
+			t.Errorf("status %d: expected 1 request, got %d", status, n)
+		}
+		if waits := clock.Waits(); len(waits) != 0 {
+			t.Errorf("status %d: expected no waits, got %v", status, waits)
+		}
+	}
+}
+
+func TestRetryLeavesPostAlone(t *testing.T) {
+	h := failingHandler(1, http.StatusServiceUnavailable)
+	c := newTestClient(t, h.ServeHTTP, WithRetry(3, time.Second), WithClock(newFakeClock()))
+
+	err := c.Post("counter", 1)
+	if code, _ := StatusCode(err); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected HTTPError with status 503, got %v", err)
+	}
+	if n := h.requests.Load(); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+}
+
+func TestRetryNetworkErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	clock := newFakeClock()
+	c, err := NewClientWithURL(srv.URL, "store", WithRetry(3, time.Second), WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewClientWithURL: %v", err)
+	}
+
+	var v int
+	err = c.Get("counter", &v)
+	if err == nil || !IsTransient(err) {
+		t.Fatalf("expected network error, got %v", err)
+	}
+	if waits := clock.Waits(); len(waits) != 2 {
+		t.Errorf("expected 2 waits, got %v", waits)
+	}
+}
+
+func TestRetrySkipsClientSideErrors(t *testing.T) {
+	clock := newFakeClock()
+	h := failingHandler(0, 0)
+	c := newTestClient(t, h.ServeHTTP, WithRetry(3, time.Second), WithClock(clock), WithRoundTripper(MaxBodySize(4)))
+
+	err := c.PutReader("counter", strings.NewReader(`"too large"`))
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge, got %v", err)
+	}
+	c.Close()
+	var v int
+	err = c.Get("counter", &v)
+	if !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+	if waits := clock.Waits(); len(waits) != 0 {
+		t.Errorf("expected no waits, got %v", waits)
+	}
+	if n := h.requests.Load(); n != 0 {
+		t.Errorf("expected no requests, got %d", n)
+	}
+}
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithRetry(3, time.Second), WithClock(newFakeClock()))
+
+	var v int
+	err := c.WithContext(ctx).Get("counter", &v)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+}
+
+func TestRetryPolicyDecidesForEveryMethod(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+		var attempts []int
+		policy := func(attempt int, resp *http.Response, err error) (bool, time.Duration) {
+			attempts = append(attempts, attempt)
+			if resp == nil || resp.StatusCode != http.StatusConflict {
+				t.Errorf("%s: expected failed 409 response, got %v", method, resp)
+			}
+			return attempt < 3, time.Second
+		}
+		clock := newFakeClock()
+		h := failingHandler(2, http.StatusConflict)
+		c := newTestClient(t, h.ServeHTTP, WithRetryPolicy(policy), WithClock(clock))
+
+		req, err := http.NewRequest(method, "counter", strings.NewReader(`1`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(`1`)), nil
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		resp.Body.Close()
+		if n := h.requests.Load(); n != 3 {
+			t.Errorf("%s: expected 3 requests, got %d", method, n)
+		}
+		if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+			t.Errorf("%s: expected policy to be called for attempts [1 2], got %v", method, attempts)
+		}
+		if waits := clock.Waits(); len(waits) != 2 || waits[0] != time.Second {
+			t.Errorf("%s: expected waits of the policy, got %v", method, waits)
+		}
+	}
+}
+
+func TestRetryPolicyCanRetryPost(t *testing.T) {
+	h := failingHandler(1, http.StatusServiceUnavailable)
+	c := newTestClient(t, h.ServeHTTP, WithRetryPolicy(DefaultRetryPolicy), WithClock(newFakeClock()))
+
+	err := c.Post("counter", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := h.requests.Load(); n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+}
+
+// recordingHandler fails the first n requests with 503 and records the body of every request.
+func recordingHandler(n int, bodies *[]string) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		*bodies = append(*bodies, string(body))
+		failed := len(*bodies) <= n
+		mu.Unlock()
+		if failed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeResult(w, `null`)
+	}
+}
+
+func TestRetryResendsBody(t *testing.T) {
+	payload := `{"title":"write tests","done":false}`
+	tests := []struct {
+		name  string
+		write func(c *HttpClient) error
+	}{
+		{"PutBytes", func(c *HttpClient) error { return c.PutBytes("todos/1", []byte(payload)) }},
+		{"Put", func(c *HttpClient) error { return c.Put("todos/1", json.RawMessage(payload)) }},
+		{"PutReader", func(c *HttpClient) error { return c.PutReader("todos/1", strings.NewReader(payload)) }},
+		{"PutReader with offset", func(c *HttpClient) error {
+			r := strings.NewReader("skipped" + payload)
+			r.Seek(int64(len("skipped")), io.SeekStart)
+			return c.PutReader("todos/1", r)
+		}},
+	}
+	for _, tt := range tests {
+		var bodies []string
+		c := newTestClient(t, recordingHandler(1, &bodies), WithRetry(3, time.Second), WithClock(newFakeClock()))
+
+		err := tt.write(c)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if len(bodies) != 2 {
+			t.Fatalf("%s: expected 2 requests, got %d", tt.name, len(bodies))
+		}
+		for i, body := range bodies {
+			if body != payload {
+				t.Errorf("%s: attempt %d: expected body %s, got %q", tt.name, i+1, payload, body)
+			}
+		}
+	}
+}
+
+func TestRetrySkipsUnreplayableReader(t *testing.T) {
+	var bodies []string
+	c := newTestClient(t, recordingHandler(1, &bodies), WithRetry(3, time.Second), WithClock(newFakeClock()))
+
+	r := io.MultiReader(strings.NewReader(`"not seekable"`))
+	err := c.PutReader("todos/1", r)
+	if code, _ := StatusCode(err); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected HTTPError with status 503, got %v", err)
+	}
+	if len(bodies) != 1 {
+		t.Errorf("expected 1 request, got %d", len(bodies))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"5", 5 * time.Second},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{now.Add(2 * time.Minute).Format(time.RFC850), 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		delay := parseRetryAfter(tt.header, now)
+		if delay != tt.expected {
+			t.Errorf("parseRetryAfter(%q): expected %s, got %s", tt.header, tt.expected, delay)
+		}
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	clock := newFakeClock()
+	tests := []struct {
+		name       string
+		retryAfter func() string
+		expected   time.Duration
+	}{
+		{"seconds", func() string { return "2" }, 2 * time.Second},
+		{"http date", func() string { return clock.Now().Add(3 * time.Second).Format(http.TimeFormat) }, 3 * time.Second},
+	}
+	for _, tt := range tests {
+		var requests atomic.Int32
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				w.Header().Set("Retry-After", tt.retryAfter())
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			writeResult(w, `1`)
+		}, WithRetry(3, 100*time.Millisecond), WithClock(clock))
+
+		before := len(clock.Waits())
+		var v int
+		err := c.Get("counter", &v)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		waits := clock.Waits()[before:]
+		if len(waits) != 1 || waits[0] != tt.expected {
+			t.Errorf("%s: expected to wait %s, waited %v", tt.name, tt.expected, waits)
+		}
+	}
+}
+
+func TestRetryReturnsLongRetryAfter(t *testing.T) {
+	for _, opt := range []Option{WithRetry(3, time.Second), WithRetryPolicy(DefaultRetryPolicy)} {
+		var requests atomic.Int32
+		clock := newFakeClock()
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, opt, WithClock(clock))
+
+		var v int
+		err := c.Get("counter", &v)
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.RetryAfter != 24*time.Hour {
+			t.Fatalf("expected HTTPError with RetryAfter 24h, got %v", err)
+		}
+		if n := requests.Load(); n != 1 {
+			t.Errorf("expected 1 request, got %d", n)
+		}
+		if waits := clock.Waits(); len(waits) != 0 {
+			t.Errorf("expected no waits, got %v", waits)
+		}
+	}
+}
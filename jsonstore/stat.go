@@ -0,0 +1,72 @@
+package jsonstore
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Kind of JSON value.
+type Kind string
+
+const (
+	KindObject Kind = "object"
+	KindArray  Kind = "array"
+	KindString Kind = "string"
+	KindNumber Kind = "number"
+	KindBool   Kind = "bool"
+	KindNull   Kind = "null"
+)
+
+// Stat describes a stored value. ByteSize is the size of its JSON as returned by jsonstore
+// and Children the number of fields of an object or elements of an array, zero for other kinds.
+type Stat struct {
+	ByteSize int
+	Kind     Kind
+	Children int
+}
+
+// Stat returns the size and kind of the value at key without decoding it.
+// Returns ErrNoValue if no value is stored at key.
+func (c *HttpClient) Stat(key string) (Stat, error) {
+	var raw json.RawMessage
+	err := c.Get(key, &raw)
+	if err != nil {
+		return Stat{}, err
+	}
+	stat := Stat{ByteSize: len(raw), Kind: kindOf(raw)}
+	switch stat.Kind {
+	case KindObject:
+		var fields map[string]json.RawMessage
+		err = json.Unmarshal(raw, &fields)
+		stat.Children = len(fields)
+	case KindArray:
+		var elements []json.RawMessage
+		err = json.Unmarshal(raw, &elements)
+		stat.Children = len(elements)
+	}
+	if err != nil {
+		return Stat{}, err
+	}
+	return stat, nil
+}
+
+func kindOf(raw json.RawMessage) Kind {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return KindNull
+	}
+	switch raw[0] {
+	case '{':
+		return KindObject
+	case '[':
+		return KindArray
+	case '"':
+		return KindString
+	case 't', 'f':
+		return KindBool
+	case 'n':
+		return KindNull
+	default:
+		return KindNumber
+	}
+}
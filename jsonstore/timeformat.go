@@ -0,0 +1,164 @@
+package jsonstore
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TimeFormatUnix is the layout for WithTimeFormat storing times as Unix timestamps in seconds.
+const TimeFormatUnix = "unix"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// WithTimeFormat makes the client store time.Time values in the format of layout, as accepted
+// by time.Format, or as Unix timestamps with TimeFormatUnix, instead of RFC 3339. Stored times
+// are parsed with the same layout when read. Times are found through the Go types of the values,
+// so times held in interface{} values are left in RFC 3339. Applies on top of the codec of the client.
+func WithTimeFormat(layout string) Option {
+	return func(c *HttpClient) {
+		c.timeFormat = layout
+	}
+}
+
+// timeFormatCodec rewrites the times in the JSON of codec between RFC 3339 and layout.
+type timeFormatCodec struct {
+	codec  Codec
+	layout string
+}
+
+func (tc timeFormatCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := tc.codec.Marshal(v)
+	if err != nil || v == nil {
+		return data, err
+	}
+	value, err := decodeValue(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tc.convert(reflect.TypeOf(v), value, tc.formatTime))
+}
+
+func (tc timeFormatCodec) Unmarshal(data []byte, v interface{}) error {
+	if v == nil {
+		return tc.codec.Unmarshal(data, v)
+	}
+	value, err := decodeValue(data)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(tc.convert(reflect.TypeOf(v), value, tc.parseTime))
+	if err != nil {
+		return err
+	}
+	return tc.codec.Unmarshal(data, v)
+}
+
+// formatTime rewrites a time marshalled by encoding/json in the layout of the codec.
+func (tc timeFormatCodec) formatTime(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return value
+	}
+	if tc.layout == TimeFormatUnix {
+		return t.Unix()
+	}
+	return t.Format(tc.layout)
+}
+
+// parseTime rewrites a time stored in the layout of the codec as expected by encoding/json.
+func (tc timeFormatCodec) parseTime(value interface{}) interface{} {
+	var t time.Time
+	switch v := value.(type) {
+	case json.Number:
+		seconds, err := v.Int64()
+		if err != nil || tc.layout != TimeFormatUnix {
+			return value
+		}
+		t = time.Unix(seconds, 0)
+	case string:
+		var err error
+		t, err = time.Parse(tc.layout, v)
+		if err != nil {
+			return value
+		}
+	default:
+		return value
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// convert applies fn to every value in the decoded JSON value that holds a time.Time in t.
+func (tc timeFormatCodec) convert(t reflect.Type, value interface{}, fn func(interface{}) interface{}) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return fn(value)
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if fields, ok := value.(map[string]interface{}); ok {
+			tc.convertFields(t, fields, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		if elements, ok := value.([]interface{}); ok {
+			for i, element := range elements {
+				elements[i] = tc.convert(t.Elem(), element, fn)
+			}
+		}
+	case reflect.Map:
+		if fields, ok := value.(map[string]interface{}); ok {
+			for name, field := range fields {
+				fields[name] = tc.convert(t.Elem(), field, fn)
+			}
+		}
+	}
+	return value
+}
+
+func (tc timeFormatCodec) convertFields(t reflect.Type, fields map[string]interface{}, fn func(interface{}) interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				tc.convertFields(embedded, fields, fn)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		for key, value := range fields {
+			if strings.EqualFold(key, name) {
+				fields[key] = tc.convert(field.Type, value, fn)
+			}
+		}
+	}
+}
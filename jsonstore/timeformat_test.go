@@ -0,0 +1,49 @@
+package jsonstore
+
+import (
+	"testing"
+	"time"
+)
+
+type timedTodo struct {
+	ID    int        `json:"id"`
+	Title string     `json:"title"`
+	Done  bool       `json:"done"`
+	Date  time.Time  `json:"date"`
+	Due   *time.Time `json:"due,omitempty"`
+}
+
+func TestTimeFormatRoundTrip(t *testing.T) {
+	date := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	due := date.Add(48 * time.Hour)
+	tests := map[string]struct {
+		stored string
+		equal  func(a, b time.Time) bool
+	}{
+		TimeFormatUnix:     {`{"date":1709285400,"done":false,"due":1709458200,"id":1,"title":"Round trip"}`, time.Time.Equal},
+		"2006-01-02 15:04": {`{"date":"2024-03-01 09:30","done":false,"due":"2024-03-03 09:30","id":1,"title":"Round trip"}`, time.Time.Equal},
+		"2006-01-02": {`{"date":"2024-03-01","done":false,"due":"2024-03-03","id":1,"title":"Round trip"}`, func(a, b time.Time) bool {
+			return a.Truncate(24 * time.Hour).Equal(b)
+		}},
+	}
+	for layout, test := range tests {
+		h := newStoreHandler()
+		c := newTestClient(t, h.ServeHTTP, WithTimeFormat(layout))
+
+		err := c.Put("todos/1", timedTodo{ID: 1, Title: "Round trip", Date: date, Due: &due})
+		if err != nil {
+			t.Fatalf("%s: %v", layout, err)
+		}
+		if stored := h.values["/store/todos/1"]; stored != test.stored {
+			t.Errorf("%s: expected %s to be stored, got %s", layout, test.stored, stored)
+		}
+		var todo timedTodo
+		err = c.Get("todos/1", &todo)
+		if err != nil {
+			t.Fatalf("%s: %v", layout, err)
+		}
+		if todo.ID != 1 || todo.Title != "Round trip" || !test.equal(date, todo.Date) || todo.Due == nil || !test.equal(due, *todo.Due) {
+			t.Errorf("%s: expected todo to round trip, got %+v", layout, todo)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package jsonstore
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WithProxy sends requests through the proxy at proxyURL. By default the client uses the
+// proxy configured by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+// An invalid proxyURL makes every request fail. Has no effect together with WithHTTPClient.
+func WithProxy(proxyURL string) Option {
+	return func(c *HttpClient) {
+		u, err := url.Parse(proxyURL)
+		c.proxy = func(*http.Request) (*url.URL, error) {
+			return u, err
+		}
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to jsonstore, e.g. to trust the
+// internal CA of a self-hosted backend set with NewClientWithURL or to present client certificates.
+// A client given with WithHTTPClient takes precedence and is used with its own TLS configuration.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *HttpClient) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle keep-alive connections kept open
+// in total and to the jsonstore host. Defaults to 100 in total and 16 per host, enough
+// for the default concurrency of batch operations to reuse connections.
+// Has no effect together with WithHTTPClient.
+func WithMaxIdleConns(total, perHost int) Option {
+	return func(c *HttpClient) {
+		c.maxIdleConns = total
+		c.maxIdlePerHost = perHost
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle keep-alive connection is kept open,
+// zero means no limit. Defaults to 90 seconds and has no effect together with WithHTTPClient.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(c *HttpClient) {
+		c.idleConnTimeout = d
+	}
+}
+
+// WithDialTimeout limits the time spent establishing a connection to d, so an unreachable
+// host fails fast while WithTimeout still leaves time to read large responses. Dialing is
+// part of the request, so d has no effect beyond WithTimeout. Defaults to 30 seconds and
+// has no effect together with WithHTTPClient.
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *HttpClient) {
+		c.dialTimeout = d
+	}
+}
+
+// WithTLSHandshakeTimeout limits the time spent on the TLS handshake of a new connection
+// to d, counted separately from WithDialTimeout and like it bounded by WithTimeout.
+// Defaults to 10 seconds and has no effect together with WithHTTPClient.
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return func(c *HttpClient) {
+		c.tlsTimeout = d
+	}
+}
+
+// createTransport creates the transport of the default http.Client from the options of the client.
+// Connections are kept alive and HTTP/2 is used when the server supports it.
+func (c *HttpClient) createTransport() *http.Transport {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = defaultTransport.Clone()
+	}
+	transport.ForceAttemptHTTP2 = true
+	transport.DisableKeepAlives = false
+	transport.MaxIdleConns = c.maxIdleConns
+	transport.MaxIdleConnsPerHost = c.maxIdlePerHost
+	transport.IdleConnTimeout = c.idleConnTimeout
+	if c.dialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: c.dialTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if c.tlsTimeout > 0 {
+		transport.TLSHandshakeTimeout = c.tlsTimeout
+	}
+	if c.proxy != nil {
+		transport.Proxy = c.proxy
+	}
+	if c.tlsConfig != nil {
+		transport.TLSClientConfig = c.tlsConfig.Clone()
+	}
+	return transport
+}
@@ -0,0 +1,57 @@
+package jsonstore
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// listenFull returns the address of a listener which never accepts connections and whose
+// backlog is full, so that connecting to it hangs like connecting to an unreachable host.
+func listenFull(t *testing.T) string {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { syscall.Close(fd) })
+	err = syscall.Bind(fd, &syscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}})
+	if err == nil {
+		err = syscall.Listen(fd, 0)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa, err := syscall.Getsockname(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := fmt.Sprintf("127.0.0.1:%d", sa.(*syscall.SockaddrInet4).Port)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return addr
+}
+
+func TestDialTimeoutFailsFast(t *testing.T) {
+	c, err := NewClientWithURL("http://"+listenFull(t), "store",
+		WithDialTimeout(50*time.Millisecond), WithTimeout(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	var v interface{}
+	err = c.Get("todos", &v)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected dial timeout to fire after 50ms, took %s", elapsed)
+	}
+}
@@ -0,0 +1,197 @@
+package jsonstore
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProxyRoutesRequests(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.Method+" "+r.URL.String())
+		writeResult(w, `1`)
+	}))
+	defer proxy.Close()
+
+	c, err := NewClientWithURL("http://jsonstore.invalid", "store", WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	err = c.Get("todos/1", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+	expected := "GET http://jsonstore.invalid/store/todos/1"
+	if len(proxied) != 1 || proxied[0] != expected {
+		t.Errorf("Expected proxy to receive [%s], got %v", expected, proxied)
+	}
+}
+
+func TestInvalidProxyFailsRequests(t *testing.T) {
+	var requests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeResult(w, `1`)
+	}, WithProxy("://invalid"))
+
+	var v int
+	err := c.Get("todos/1", &v)
+	if err == nil {
+		t.Error("Expected request through an invalid proxy to fail")
+	}
+	if requests != 0 {
+		t.Errorf("Expected no requests to reach the server, got %d", requests)
+	}
+}
+
+// benchmarkConcurrentGets measures the throughput of parallel gets from a local TLS server,
+// where every new connection costs a handshake, and reports the connections opened per get.
+// The server takes a millisecond to respond so that many requests are in flight at once.
+// newClient creates the client under test.
+func benchmarkConcurrentGets(b *testing.B, newClient func(url string, tlsConfig *tls.Config) (*HttpClient, error)) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		writeResult(w, `{"title":"Write benchmarks","done":false}`)
+	}))
+	defer srv.Close()
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	var conns atomic.Int32
+	srv.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	c, err := newClient(srv.URL, srv.Client().Transport.(*http.Transport).TLSClientConfig)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	b.SetParallelism(64)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var v map[string]interface{}
+			if err := c.Get("todos/1", &v); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+}
+
+func BenchmarkConcurrentGetsTunedTransport(b *testing.B) {
+	benchmarkConcurrentGets(b, func(url string, tlsConfig *tls.Config) (*HttpClient, error) {
+		return NewClientWithURL(url, "store", WithTLSConfig(tlsConfig))
+	})
+}
+
+func BenchmarkConcurrentGetsZeroTransport(b *testing.B) {
+	benchmarkConcurrentGets(b, func(url string, tlsConfig *tls.Config) (*HttpClient, error) {
+		hc := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		return NewClientWithURL(url, "store", WithHTTPClient(hc))
+	})
+}
+
+func TestTransportTimeouts(t *testing.T) {
+	c, err := NewClientWithURL("http://localhost", "store",
+		WithDialTimeout(time.Second), WithTLSHandshakeTimeout(2*time.Second), WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := c.httpClient.Transport.(*http.Transport)
+	if transport.DialContext == nil {
+		t.Error("Expected dial timeout to set a dialer")
+	}
+	if transport.TLSHandshakeTimeout != 2*time.Second {
+		t.Errorf("Expected TLS handshake timeout of 2s, got %s", transport.TLSHandshakeTimeout)
+	}
+	if c.httpClient.Timeout != time.Minute {
+		t.Errorf("Expected overall timeout of 1m, got %s", c.httpClient.Timeout)
+	}
+}
+
+// connCounter starts a server counting its open connections.
+func connCounter(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var open atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, `1`)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		switch s {
+		case http.StateNew:
+			open.Add(1)
+		case http.StateClosed, http.StateHijacked:
+			open.Add(-1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, &open
+}
+
+// waitForConns waits up to a second for the number of open connections to be n.
+func waitForConns(open *atomic.Int32, n int32) int32 {
+	deadline := time.Now().Add(time.Second)
+	for open.Load() != n && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	return open.Load()
+}
+
+func TestCloseReleasesConnectionsBehindMiddleware(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"no middleware": nil,
+		"MaxBodySize":   {WithRoundTripper(MaxBodySize(1024))},
+	} {
+		srv, open := connCounter(t)
+		c, err := NewClientWithURL(srv.URL, "store", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v int
+		err = c.Get("todos/1", &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := waitForConns(open, 1); n != 1 {
+			t.Fatalf("%s: expected 1 open connection, got %d", name, n)
+		}
+		c.Close()
+		if n := waitForConns(open, 0); n != 0 {
+			t.Errorf("%s: expected Close to release the idle connection, %d still open", name, n)
+		}
+	}
+}
+
+func TestCloseLeavesCallerHTTPClientOpen(t *testing.T) {
+	srv, open := connCounter(t)
+	hc := &http.Client{Transport: &http.Transport{}}
+	defer hc.CloseIdleConnections()
+	c, err := NewClientWithURL(srv.URL, "store", WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	err = c.Get("todos/1", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	time.Sleep(50 * time.Millisecond)
+	if n := open.Load(); n != 1 {
+		t.Errorf("Expected the connection of the caller's http.Client to stay open, got %d open", n)
+	}
+}
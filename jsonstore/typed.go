@@ -0,0 +1,23 @@
+package jsonstore
+
+// TypedClient wraps a Client to get and set values of a single type.
+type TypedClient[T any] struct {
+	c Client
+}
+
+// NewTyped creates a new TypedClient for values of type T.
+func NewTyped[T any](c Client) *TypedClient[T] {
+	return &TypedClient[T]{c: c}
+}
+
+// Get gets the value stored at key.
+func (t *TypedClient[T]) Get(key string) (T, error) {
+	var v T
+	err := t.c.Get(key, &v)
+	return v, err
+}
+
+// Set stores a value at key.
+func (t *TypedClient[T]) Set(key string, v T) error {
+	return t.c.Post(key, v)
+}
@@ -0,0 +1,65 @@
+package jsonstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxUpdateAttempts is the number of times Update calls fn before giving up on a key
+// that keeps changing between the read and the write.
+const maxUpdateAttempts = 5
+
+// Update reads the value of key, passes it to fn and writes back the value fn returns.
+// current is nil if no value is stored at key. An error returned by fn aborts the
+// update and is returned as is.
+//
+// If the server sends an ETag the write is conditional and the read, fn and the write
+// are retried whenever the value was changed in between, so fn may be called more than
+// once. Without ETags the write is unconditional and concurrent writes can be lost.
+func (c *HttpClient) Update(key string, fn func(current json.RawMessage) (interface{}, error)) error {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		var current json.RawMessage
+		etag, err := c.GetWithETag(key, &current)
+		exists := err == nil
+		if err != nil && !errors.Is(err, ErrNoValue) {
+			return err
+		}
+		next, err := fn(current)
+		if err != nil {
+			return err
+		}
+		if etag == "" {
+			return c.set(key, next, exists)
+		}
+		err = c.PutIfMatch(key, next, etag)
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return err
+		}
+	}
+	return fmt.Errorf("Could not update '%s', value changed on each of %d attempts: %w", key, maxUpdateAttempts, ErrPreconditionFailed)
+}
+
+// Append adds v to the end of the array stored at key, creating the array if no value
+// is stored there. jsonstore has no native append, so Append reads the array and writes
+// it back with Update: with ETags concurrent appends are retried and none are lost,
+// without them concurrent appends can overwrite each other.
+func (c *HttpClient) Append(key string, v interface{}) error {
+	element, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Update(key, func(current json.RawMessage) (interface{}, error) {
+		var elements []json.RawMessage
+		if current != nil && kindOf(current) != KindNull {
+			if kindOf(current) != KindArray {
+				return nil, fmt.Errorf("Could not append to '%s': value is not an array", key)
+			}
+			err := json.Unmarshal(current, &elements)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(elements, element), nil
+	})
+}
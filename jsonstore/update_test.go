@@ -0,0 +1,81 @@
+package jsonstore_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore"
+	"github.com/CzarSimon/jsonstore-go-client/jsonstore/jsonstoretest"
+)
+
+func TestIncrementConcurrentClientsNoDuplicateIDs(t *testing.T) {
+	srv, _ := jsonstoretest.NewServer()
+	defer srv.Close()
+
+	const clients, increments = 4, 10
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		client, err := jsonstore.NewClientWithURL(srv.URL, jsonstoretest.StoreKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				id, err := client.Increment("counter", 1)
+				// Update gives up after a few conflicting writes, callers retry.
+				for errors.Is(err, jsonstore.ErrPreconditionFailed) {
+					id, err = client.Increment("counter", 1)
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("Increment returned duplicate id %d", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	client, _ := jsonstore.NewClientWithURL(srv.URL, jsonstoretest.StoreKey)
+	var count int
+	err := client.Get("counter", &count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != clients*increments {
+		t.Errorf("Expected counter to be %d, got %d", clients*increments, count)
+	}
+	if len(seen) != clients*increments {
+		t.Errorf("Expected %d distinct ids, got %d", clients*increments, len(seen))
+	}
+}
+
+func TestIncrementStartsFromZero(t *testing.T) {
+	srv, client := jsonstoretest.NewServer()
+	defer srv.Close()
+
+	id, err := client.Increment("counter", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 5 {
+		t.Errorf("Expected 5, got %d", id)
+	}
+	id, err = client.Increment("counter", -2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 3 {
+		t.Errorf("Expected 3, got %d", id)
+	}
+}
@@ -0,0 +1,93 @@
+package jsonstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// Walk gets everything stored beneath prefix in a single request and calls fn with the
+// key and raw JSON of each leaf, that is each scalar value or empty object or array,
+// in key order. An empty prefix walks the whole store. Walk stops and returns the error
+// if fn returns one or ctx is done. Returns ErrNoValue if nothing is stored at prefix.
+func (c *HttpClient) Walk(ctx context.Context, prefix string, fn func(key string, value json.RawMessage) error) error {
+	tree, err := c.tree(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	return walkValue(ctx, prefix, tree, true, fn)
+}
+
+// tree gets everything stored beneath prefix, returning ErrNoValue if nothing is.
+func (c *HttpClient) tree(ctx context.Context, prefix string) (json.RawMessage, error) {
+	client := c.With()
+	client.ctx = ctx
+	rawResponse, err := client.getTree(prefix)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.parseResponse(http.MethodGet, prefix, rawResponse)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.HasResult() {
+		return nil, ErrNoValue
+	}
+	return resp.Result, nil
+}
+
+// walkValue calls fn with each leaf of value stored at key. Arrays are leaves
+// unless intoArrays is set, in which case fn is called with each element.
+func walkValue(ctx context.Context, key string, value json.RawMessage, intoArrays bool, fn func(string, json.RawMessage) error) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	switch kindOf(value) {
+	case KindObject:
+		var fields map[string]json.RawMessage
+		err = json.Unmarshal(value, &fields)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			return fn(key, value)
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			err = walkValue(ctx, path.Join(key, name), fields[name], intoArrays, fn)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case KindArray:
+		if !intoArrays {
+			return fn(key, value)
+		}
+		var elements []json.RawMessage
+		err = json.Unmarshal(value, &elements)
+		if err != nil {
+			return err
+		}
+		if len(elements) == 0 {
+			return fn(key, value)
+		}
+		for i, element := range elements {
+			err = walkValue(ctx, path.Join(key, strconv.Itoa(i)), element, intoArrays, fn)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fn(key, value)
+	}
+}
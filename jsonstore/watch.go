@@ -0,0 +1,158 @@
+package jsonstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WatchEvent describes a change to one of the watched keys.
+type WatchEvent struct {
+	Key  string
+	Data []byte
+	Err  error
+
+	client *HttpClient
+}
+
+// Decode unmarshals the result of the watched value into v.
+func (e WatchEvent) Decode(v interface{}) error {
+	if e.Err != nil {
+		return e.Err
+	}
+	return e.client.decodeResult(e.Key, e.Data, v)
+}
+
+// WatchMany polls all keys once every interval and emits an event for each key
+// whose value differs from the previous poll. The first poll emits the current
+// value of every key. Failed fetches are emitted as events with Err set.
+// The returned channel is closed when ctx is cancelled.
+func (c *HttpClient) WatchMany(ctx context.Context, keys []string, interval time.Duration) (<-chan WatchEvent, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("No keys to watch")
+	}
+	if interval <= 0 {
+		return nil, errors.New("Watch interval must be positive")
+	}
+	events := make(chan WatchEvent)
+	go c.watchMany(ctx, keys, interval, events)
+	return events, nil
+}
+
+func (c *HttpClient) watchMany(ctx context.Context, keys []string, interval time.Duration, events chan<- WatchEvent) {
+	defer close(events)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	client := c.With()
+	client.ctx = ctx
+	last := make(map[string][]byte, len(keys))
+	for {
+		for _, event := range client.pollKeys(keys) {
+			if event.Err == nil {
+				prev, seen := last[event.Key]
+				if seen && bytes.Equal(prev, event.Data) {
+					continue
+				}
+				last[event.Key] = event.Data
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *HttpClient) pollKeys(keys []string) []WatchEvent {
+	results := make([]WatchEvent, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			data, err := c.GetBytes(key)
+			results[i] = WatchEvent{Key: key, Data: data, Err: err, client: c}
+		}(i, key)
+	}
+	wg.Wait()
+	return results
+}
+
+// PollEvent carries a value fetched by Poll. Value holds a pointer of the type given to
+// Poll with the decoded value, or is nil if Err is set.
+type PollEvent struct {
+	Key   string
+	Value interface{}
+	ETag  string
+	Err   error
+}
+
+// Poll fetches key once every interval and emits an event holding the decoded value each
+// time it has changed, starting with the current value. v is only used for its type, a new
+// value of the type it points to is decoded for every event. If the server sends ETags the
+// value is fetched with GetIfChanged, so unchanged values are not transferred again. Failed
+// fetches are emitted as events with Err set. The returned channel is closed when ctx is cancelled.
+func (c *HttpClient) Poll(ctx context.Context, key string, interval time.Duration, v interface{}) (<-chan PollEvent, error) {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil, errors.New("Poll value must be a non-nil pointer")
+	}
+	if interval <= 0 {
+		return nil, errors.New("Poll interval must be positive")
+	}
+	events := make(chan PollEvent)
+	go c.poll(ctx, key, interval, t.Elem(), events)
+	return events, nil
+}
+
+func (c *HttpClient) poll(ctx context.Context, key string, interval time.Duration, t reflect.Type, events chan<- PollEvent) {
+	defer close(events)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	client := c.With()
+	client.ctx = ctx
+	var etag string
+	var last []byte
+	for {
+		var raw json.RawMessage
+		newETag, changed, err := client.GetIfChanged(key, etag, &raw)
+		if err == nil && changed {
+			// Servers without ETags send the value every time.
+			etag = newETag
+			changed = last == nil || !bytes.Equal(last, raw)
+			last = raw
+		}
+		var value interface{}
+		if err == nil && changed {
+			value = reflect.New(t).Interface()
+			err = c.codec.Unmarshal(raw, value)
+		}
+		if err != nil || changed {
+			if err != nil {
+				value = nil
+			}
+			select {
+			case events <- PollEvent{Key: key, Value: value, ETag: etag, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package jsonstore
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchManyEmitsChanges(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]string{"/store/a": `1`, "/store/b": `"x"`}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		writeResult(w, values[r.URL.Path])
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.WatchMany(ctx, []string{"a", "b"}, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	initial := map[string]bool{}
+	for len(initial) < 2 {
+		event := <-events
+		if event.Err != nil {
+			t.Fatalf("unexpected error event: %v", event.Err)
+		}
+		initial[event.Key] = true
+	}
+
+	mu.Lock()
+	values["/store/a"] = `2`
+	mu.Unlock()
+	event := <-events
+	var v int
+	if event.Key != "a" || event.Decode(&v) != nil || v != 2 {
+		t.Fatalf("expected change of a to 2, got %+v", event)
+	}
+}
+
+func TestWatchManyStopsOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}, WithTimeout(0))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := c.WatchMany(ctx, []string{"a", "b"}, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			for range events {
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the channel to be closed after cancel while requests are in flight")
+	}
+}
+
+func TestWatchManyRejectsInvalidArguments(t *testing.T) {
+	c := NewClient("store")
+	if _, err := c.WatchMany(context.Background(), nil, time.Second); err == nil {
+		t.Error("expected error for no keys")
+	}
+	if _, err := c.WatchMany(context.Background(), []string{"a"}, 0); err == nil {
+		t.Error("expected error for non positive interval")
+	}
+}